@@ -4,9 +4,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/svex99/bind-api/handlers"
 	"github.com/svex99/bind-api/middlewares"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/bind"
+	"github.com/svex99/bind-api/services/fleet"
 )
 
-func SetupRouter(logRequests bool) *gin.Engine {
+// Sets up the API routes served by the given backend.
+// Routes specific to BIND, like keys or DNSSEC, are only available with the BIND backend, or under
+// /api/servers/:server for each server of a fleet, and the routes of the servers only with a fleet.
+func SetupRouter(logRequests bool, dnsBackend backend.Backend) *gin.Engine {
 	router := gin.New()
 
 	router.UseRawPath = true
@@ -17,17 +23,88 @@ func SetupRouter(logRequests bool) *gin.Engine {
 		router.Use(gin.Logger())
 	}
 
+	bindService, isBind := dnsBackend.(*bind.BindService)
+	if isBind && bindService.Git != nil {
+		router.Use(middlewares.RecordChanges(bindService))
+	}
+
+	fleetBackend, isFleet := dnsBackend.(*fleet.Fleet)
+	if isFleet && fleetBackend.Recording() {
+		router.Use(middlewares.RecordChanges(fleetBackend))
+	}
+
+	h := handlers.NewHandlers(dnsBackend)
+
 	api := router.Group("/api")
 	// domain handlers
-	api.GET("/zones", handlers.ListZones)
-	api.GET("/zones/:origin", handlers.GetZone)
-	api.POST("/zones", handlers.NewZone)
-	api.PATCH("/zones", handlers.PatchZone)
-	api.DELETE("/zones/:origin", handlers.DeleteZone)
+	api.GET("/zones", h.ListZones)
+	api.GET("/zones/:origin", h.GetZone)
+	api.POST("/zones", h.NewZone)
+	api.PATCH("/zones", h.PatchZone)
+	api.DELETE("/zones/:origin", h.DeleteZone)
+	api.POST("/zones/:origin/reload", h.ReloadZone)
+	api.GET("/zones/:origin/validate", h.ValidateZone)
 	// record handlers
-	api.POST("/zones/:origin/records", handlers.PostRecord)
-	api.PATCH("/zones/:origin/records/:target", handlers.PatchRecord)
-	api.DELETE("/zones/:origin/records", handlers.DeleteRecord)
+	api.POST("/zones/:origin/records", h.PostRecord)
+	api.PATCH("/zones/:origin/records/:target", h.PatchRecord)
+	api.DELETE("/zones/:origin/records", h.DeleteRecord)
+	// server handlers
+	api.POST("/reload", h.Reload)
+
+	if isFleet {
+		// fleet handlers
+		api.GET("/servers", h.ListServers)
+		api.GET("/servers/drift", h.GetDrift)
+		api.PUT("/zones/:origin/servers", h.PutZoneServers)
+
+		// Each server of the fleet serves the routes specific to BIND on its own
+		setupBindRoutes(api.Group("/servers/:server"), h)
+	} else if isBind {
+		setupBindRoutes(api, h)
+	}
 
 	return router
 }
+
+// Sets up the routes specific to BIND, served by a single server.
+func setupBindRoutes(api *gin.RouterGroup, h *handlers.Handlers) {
+	// dnssec handlers
+	api.GET("/zones/:origin/dnssec", h.GetZoneDnssec)
+	api.PUT("/zones/:origin/dnssec", h.PutZoneDnssec)
+	api.GET("/zones/:origin/dnssec/keys", h.GetZoneKeys)
+	// dynamic update handlers
+	api.GET("/zones/:origin/dynamic", h.GetZoneUpdates)
+	api.PUT("/zones/:origin/dynamic", h.PutZoneUpdates)
+	// history handlers
+	api.GET("/zones/:origin/versions", h.ListZoneVersions)
+	api.GET("/zones/:origin/versions/diff", h.DiffZoneVersions)
+	api.POST("/zones/:origin/versions/:id/restore", h.RestoreZoneVersion)
+	api.GET("/config/versions", h.ListConfVersions)
+	api.GET("/config/versions/diff", h.DiffConfVersions)
+	api.POST("/config/versions/:id/restore", h.RestoreConfVersion)
+	// write-behind handlers
+	api.GET("/queue/:id", h.GetQueuedChange)
+	// git store handlers
+	api.GET("/changes", h.ListChanges)
+	api.GET("/zones/:origin/changes", h.ListZoneChanges)
+	// key handlers
+	api.GET("/keys", h.ListKeys)
+	api.POST("/keys", h.NewKey)
+	api.POST("/keys/:name/rotate", h.RotateKey)
+	api.DELETE("/keys/:name", h.DeleteKey)
+	// catalog handlers
+	api.GET("/catalog", h.GetCatalog)
+	// response policy zone handlers
+	api.GET("/rpz", h.ListRPZones)
+	api.GET("/rpz/:name", h.GetRPZone)
+	api.POST("/rpz", h.NewRPZone)
+	api.DELETE("/rpz/:name", h.DeleteRPZone)
+	api.PUT("/rpz/:name/rules", h.PutRPZRule)
+	api.DELETE("/rpz/:name/rules/:domain", h.DeleteRPZRule)
+	api.PUT("/rpz/:name/blocklist", h.PutBlocklist)
+	// status handlers
+	api.GET("/status", h.GetStatus)
+	// options handlers
+	api.GET("/options", h.GetOptions)
+	api.PATCH("/options", h.PatchOptions)
+}
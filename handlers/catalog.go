@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) GetCatalog(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	catalog, err := bindService.GetCatalog()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, catalog)
+}
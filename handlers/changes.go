@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Commits returned when the `limit` query parameter is missing
+const defaultChangesLimit = 50
+
+func (h *Handlers) ListChanges(c *gin.Context) {
+	h.listChanges(c, "")
+}
+
+func (h *Handlers) ListZoneChanges(c *gin.Context) {
+	h.listChanges(c, c.Param("origin"))
+}
+
+func (h *Handlers) listChanges(c *gin.Context, origin string) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	limit := defaultChangesLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+	}
+
+	commits, err := bindService.ListChanges(origin, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, commits)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/pkg/dnssec"
+	"github.com/svex99/bind-api/schemas"
+)
+
+func (h *Handlers) GetZoneDnssec(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	origin := c.Param("origin")
+
+	status, err := bindService.GetZoneDnssec(origin)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *Handlers) PutZoneDnssec(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	origin := c.Param("origin")
+
+	var data schemas.DnssecData
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := bindService.SetZoneDnssec(origin, &data)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Lists the DNSSEC keys of a zone with their DS records.
+// The digest types of the DS records can be selected with the `digest` query, like `?digest=SHA-256,SHA-384`.
+func (h *Handlers) GetZoneKeys(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	origin := c.Param("origin")
+
+	digests := []uint8{}
+	if query := c.Query("digest"); query != "" {
+		for _, name := range strings.Split(query, ",") {
+			digest, err := dnssec.DigestType(name)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			digests = append(digests, digest)
+		}
+	}
+
+	keys, err := bindService.GetZoneKeys(origin, digests)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/schemas"
+)
+
+func (h *Handlers) GetZoneUpdates(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	updates, err := bindService.GetZoneUpdates(c.Param("origin"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, updates)
+}
+
+func (h *Handlers) PutZoneUpdates(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	var data schemas.DynamicUpdateData
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates, err := bindService.SetZoneUpdates(c.Param("origin"), &data)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updates)
+}
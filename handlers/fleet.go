@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/fleet"
+)
+
+// Returns the fleet serving the requests, or responds with an error if the backend is a single server.
+func (h *Handlers) fleet(c *gin.Context) *fleet.Fleet {
+	fleetBackend, ok := h.requestBackend(c).(*fleet.Fleet)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "the backend is not a fleet of servers"})
+		return nil
+	}
+
+	return fleetBackend
+}
+
+// Returns the backend of the server in the `server` path parameter, or the backend itself on the routes
+// without one. Responds with an error if the server does not exist.
+func (h *Handlers) server(c *gin.Context) backend.Backend {
+	name := c.Param("server")
+	if name == "" {
+		return h.requestBackend(c)
+	}
+
+	fleetBackend := h.fleet(c)
+	if fleetBackend == nil {
+		return nil
+	}
+
+	server, err := fleetBackend.Server(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	return server
+}
+
+// Returns the backend to apply a change of the request with. Changes of a fleet are made through a view
+// of it, which keeps the result of the change in each server for respondChange.
+func (h *Handlers) changeBackend(c *gin.Context) backend.Backend {
+	dnsBackend := h.requestBackend(c)
+	if fleetBackend, ok := dnsBackend.(*fleet.Fleet); ok {
+		return fleetBackend.WithResults()
+	}
+
+	return dnsBackend
+}
+
+// Responds with the body of a change that succeeded. Changes made through a view of a fleet add the result
+// in each server to the body, in the `servers` field, so the responses without content become 200 OK.
+func respondChange(c *gin.Context, code int, body any, dnsBackend backend.Backend) {
+	fleetBackend, ok := dnsBackend.(*fleet.Fleet)
+	if !ok {
+		c.JSON(code, body)
+		return
+	}
+
+	fields := gin.H{}
+
+	content, err := json.Marshal(body)
+	if err == nil {
+		err = json.Unmarshal(content, &fields)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields["servers"] = fleetBackend.Results()
+
+	if code == http.StatusNoContent {
+		code = http.StatusOK
+	}
+
+	c.JSON(code, fields)
+}
+
+func (h *Handlers) ListServers(c *gin.Context) {
+	fleetBackend := h.fleet(c)
+	if fleetBackend == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, fleetBackend.Servers())
+}
+
+// Returns the zones whose content differs between the servers holding them.
+func (h *Handlers) GetDrift(c *gin.Context) {
+	fleetBackend := h.fleet(c)
+	if fleetBackend == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, fleetBackend.Drift())
+}
+
+func (h *Handlers) PutZoneServers(c *gin.Context) {
+	fleetBackend := h.fleet(c)
+	if fleetBackend == nil {
+		return
+	}
+
+	var data schemas.ZoneServersData
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := fleetBackend.AssignZone(c.Param("origin"), data.Servers)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"servers": results})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/middlewares"
+	"github.com/svex99/bind-api/pkg/file"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/bind"
+	"github.com/svex99/bind-api/services/bind/parser"
+	"github.com/svex99/bind-api/services/fleet"
+)
+
+// Handlers of the API, served by the backend they are created with.
+type Handlers struct {
+	Backend backend.Backend
+}
+
+func NewHandlers(dnsBackend backend.Backend) *Handlers {
+	return &Handlers{Backend: dnsBackend}
+}
+
+// Returns the backend serving the request, the view of it set by middlewares.RecordChanges if any,
+// so the files changed by the request are recorded with it.
+func (h *Handlers) requestBackend(c *gin.Context) backend.Backend {
+	if view, ok := c.Get(middlewares.BackendKey); ok {
+		return view.(backend.Backend)
+	}
+
+	return h.Backend
+}
+
+// Returns the BIND service of the server the request is for, or responds with an error if it is not one.
+// Routes specific to BIND name a server of the fleet in the `server` path parameter, otherwise the
+// backend itself is the BIND service.
+func (h *Handlers) bindServer(c *gin.Context) *bind.BindService {
+	server := h.server(c)
+	if server == nil {
+		return nil
+	}
+
+	bindService, ok := server.(*bind.BindService)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "the server is not a BIND server"})
+		return nil
+	}
+
+	return bindService
+}
+
+// Responds with the error, along with the issues found if the change did not pass the zone validation.
+// Changes to files edited out of the API, and not loaded yet, are a conflict. Changes of a fleet that
+// failed in some servers report the result in each of them, with a multi-status if others applied it.
+func errorResponse(c *gin.Context, code int, err error) {
+	body := gin.H{"error": err.Error()}
+
+	var fanOutErr *fleet.FanOutError
+	if errors.As(err, &fanOutErr) {
+		body["servers"] = fanOutErr.Results
+		if fanOutErr.Applied() {
+			c.JSON(http.StatusMultiStatus, body)
+			return
+		}
+	}
+
+	var validationErr *parser.ValidationError
+	if errors.As(err, &validationErr) {
+		body["issues"] = validationErr.Issues
+		c.JSON(http.StatusUnprocessableEntity, body)
+		return
+	}
+
+	var conflictErr *file.ConflictError
+	if errors.As(err, &conflictErr) {
+		c.JSON(http.StatusConflict, body)
+		return
+	}
+
+	c.JSON(code, body)
+}
+
+func (h *Handlers) Reload(c *gin.Context) {
+	dnsBackend := h.changeBackend(c)
+
+	if err := dnsBackend.Reload(""); err != nil {
+		errorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondChange(c, http.StatusNoContent, gin.H{}, dnsBackend)
+}
+
+func (h *Handlers) ReloadZone(c *gin.Context) {
+	dnsBackend := h.changeBackend(c)
+
+	if err := dnsBackend.Reload(c.Param("origin")); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	respondChange(c, http.StatusNoContent, gin.H{}, dnsBackend)
+}
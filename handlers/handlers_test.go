@@ -0,0 +1,262 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/api"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/bind/parser"
+	"github.com/svex99/bind-api/services/fleet"
+	"github.com/svex99/bind-api/services/memory"
+)
+
+func serve(t *testing.T, handler http.Handler, method, url string, data any) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+
+	if data != nil {
+		if err := json.NewEncoder(&body).Encode(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestZoneHandlers(t *testing.T) {
+	dnsBackend := memory.NewBackend()
+	router := api.SetupRouter(false, dnsBackend)
+
+	zone := schemas.ZoneData{
+		Origin:     "example.com",
+		Ttl:        "1d",
+		NameServer: "ns1",
+		Admin:      "admin",
+		Refresh:    3600,
+		Retry:      600,
+		Expire:     86400,
+		Minimum:    60,
+	}
+
+	w := serve(t, router, "POST", "/api/zones", zone)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = serve(t, router, "POST", "/api/zones", zone)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = serve(t, router, "POST", "/api/zones/example.com/records", map[string]string{"type": "A", "name": "ns1", "ip": "10.0.0.1"})
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = serve(t, router, "GET", "/api/zones/example.com", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(t, router, "GET", "/api/zones/example.com/validate", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"issues": []}`, w.Body.String())
+
+	zConf, err := dnsBackend.GetZone("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []parser.Record{parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"}}, zConf.Records)
+
+	w = serve(t, router, "DELETE", "/api/zones/example.com/records", map[string]string{"type": "A", "name": "ns1", "ip": "10.0.0.1"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(t, router, "POST", "/api/zones/example.com/reload", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = serve(t, router, "DELETE", "/api/zones/example.com", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = serve(t, router, "GET", "/api/zones/example.com", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	// BIND specific routes are not served by other backends
+	w = serve(t, router, "GET", "/api/keys", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestFleetHandlers(t *testing.T) {
+	ns1, ns2 := memory.NewBackend(), memory.NewBackend()
+
+	fleetBackend, err := fleet.New(&fleet.Server{Name: "ns1", Backend: ns1}, &fleet.Server{Name: "ns2", Backend: ns2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := api.SetupRouter(false, fleetBackend)
+
+	zone := schemas.ZoneData{
+		Origin: "example.com", Ttl: "1d", NameServer: "ns1", Admin: "admin",
+		Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+		Servers: []string{"ns1"},
+	}
+
+	// Changes respond with the result in each server
+	w := serve(t, router, "POST", "/api/zones", zone)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"origin":"example.com"`)
+	assert.Contains(t, w.Body.String(), `"servers":[{"server":"ns1"}]`)
+
+	w = serve(t, router, "PUT", "/api/zones/example.com/servers", schemas.ZoneServersData{Servers: []string{"ns1", "ns2"}})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"servers": [{"server": "ns2"}]}`, w.Body.String())
+
+	w = serve(t, router, "GET", "/api/servers", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `[{"name": "ns1", "zones": ["example.com"]}, {"name": "ns2", "zones": ["example.com"]}]`, w.Body.String())
+
+	record := map[string]string{"type": "A", "name": "www", "ip": "10.0.0.1"}
+	if err := ns2.AddRecord("example.com", parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w = serve(t, router, "GET", "/api/servers/drift", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"origin":"example.com"`)
+
+	// Changes applied in some servers only respond with the result in each of them
+	w = serve(t, router, "POST", "/api/zones/example.com/records", record)
+	assert.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `{"server":"ns1"}`)
+
+	w = serve(t, router, "GET", "/api/servers/drift", nil)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	w = serve(t, router, "POST", "/api/zones/example.com/reload", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"servers": [{"server": "ns1"}, {"server": "ns2"}]}`, w.Body.String())
+
+	// Routes specific to BIND are served by each server of the fleet
+	w = serve(t, router, "GET", "/api/servers/ns1/keys", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "not a BIND server")
+
+	bindService, _ := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "key \"ddns\" {\n\talgorithm hmac-sha256;\n\tsecret \"c2VjcmV0\";\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+	}, nil)
+
+	bindFleet, err := fleet.New(&fleet.Server{Name: "ns1", Backend: ns1}, &fleet.Server{Name: "ns3", Backend: bindService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router = api.SetupRouter(false, bindFleet)
+
+	w = serve(t, router, "GET", "/api/servers/ns3/keys", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"name":"ddns"`)
+
+	w = serve(t, router, "GET", "/api/servers/ns4/keys", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = serve(t, router, "GET", "/api/keys", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestRecordChanges(t *testing.T) {
+	gitDir := t.TempDir()
+
+	bindService, _ := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.GitDir = gitDir
+	})
+	router := api.SetupRouter(false, bindService)
+
+	// Requests served at the same time commit the files they changed, and no others
+	wg := sync.WaitGroup{}
+	for _, origin := range []string{"example.com", "example.org"} {
+		wg.Add(1)
+		go func(origin string) {
+			defer wg.Done()
+
+			zone := schemas.ZoneData{
+				Origin: origin, Ttl: "1d", NameServer: "ns1", Admin: "admin",
+				Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+			}
+			w := serve(t, router, "POST", "/api/zones", zone)
+			assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		}(origin)
+	}
+	wg.Wait()
+
+	for _, origin := range []string{"example.com", "example.org"} {
+		commits, err := bindService.ListChanges(origin, 10)
+		if assert.Nil(t, err) && assert.Len(t, commits, 1) {
+			assert.Equal(t, "POST /api/zones", commits[0].Endpoint)
+		}
+	}
+
+	// The change is applied, but the client is told it could not be recorded
+	if err := os.RemoveAll(gitDir); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(t, router, "POST", "/api/zones/example.com/records", map[string]string{"type": "A", "name": "www", "ip": "10.0.0.1"})
+	assert.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "not recorded")
+}
+
+func TestQueueHandlers(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records:   []parser.Record{parser.NSRecord{Type: "NS", NameServer: "ns1"}},
+	}
+
+	bindService, _ := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.WriteBehind = 50 * time.Millisecond
+	})
+	router := api.SetupRouter(false, bindService)
+
+	record := map[string]string{"type": "A", "name": "www", "ip": "10.0.0.1"}
+
+	// Queued changes are polled under the same group as the route that queued them
+	w := serve(t, router, "POST", "/api/zones/example.com/records?wait=false", record)
+	if assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String()) {
+		location := w.Header().Get("Location")
+		assert.Regexp(t, "^/api/queue/", location)
+
+		assert.Eventually(t, func() bool {
+			w := serve(t, router, "GET", location, nil)
+			return w.Code == http.StatusOK && strings.Contains(w.Body.String(), `"applied"`)
+		}, 2*time.Second, 20*time.Millisecond)
+	}
+
+	// Backends that do not batch changes refuse to queue them instead of waiting
+	fleetBackend, err := fleet.New(&fleet.Server{Name: "ns1", Backend: bindService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dnsBackend := range []backend.Backend{fleetBackend, memory.NewBackend()} {
+		w = serve(t, api.SetupRouter(false, dnsBackend), "POST", "/api/zones/example.com/records?wait=false", record)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
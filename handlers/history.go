@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+// Versions of a zone file are served under /zones/:origin/versions and the ones of
+// named.conf.local under /config/versions, an empty origin selects the latter.
+
+func (h *Handlers) ListZoneVersions(c *gin.Context) {
+	h.listVersions(c, c.Param("origin"))
+}
+
+func (h *Handlers) DiffZoneVersions(c *gin.Context) {
+	h.diffVersions(c, c.Param("origin"))
+}
+
+func (h *Handlers) RestoreZoneVersion(c *gin.Context) {
+	h.restoreVersion(c, c.Param("origin"))
+}
+
+func (h *Handlers) ListConfVersions(c *gin.Context) {
+	h.listVersions(c, "")
+}
+
+func (h *Handlers) DiffConfVersions(c *gin.Context) {
+	h.diffVersions(c, "")
+}
+
+func (h *Handlers) RestoreConfVersion(c *gin.Context) {
+	h.restoreVersion(c, "")
+}
+
+func (h *Handlers) listVersions(c *gin.Context, origin string) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	versions, err := bindService.ListVersions(origin)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// Returns the differences between the versions in the `from` and `to` query parameters.
+func (h *Handlers) diffVersions(c *gin.Context, origin string) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to versions are required"})
+		return
+	}
+
+	diff, err := bindService.DiffVersions(origin, from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "diff": diff})
+}
+
+func (h *Handlers) restoreVersion(c *gin.Context, origin string) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	if err := bindService.RestoreVersion(origin, c.Param("id")); err != nil {
+		if errors.Is(err, file.ErrVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{})
+}
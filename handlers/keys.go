@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func (h *Handlers) ListKeys(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, bindService.ListKeys())
+}
+
+func (h *Handlers) NewKey(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	var data schemas.KeyData
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := bindService.CreateKey(&data)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, schemas.KeySecret{Name: key.Name, Algorithm: key.Algorithm, Secret: key.Secret})
+}
+
+func (h *Handlers) RotateKey(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	name := c.Param("name")
+
+	key, err := bindService.RotateKey(name)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas.KeySecret{Name: key.Name, Algorithm: key.Algorithm, Secret: key.Secret})
+}
+
+func (h *Handlers) DeleteKey(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	name := c.Param("name")
+
+	if err := bindService.DeleteKey(name); err != nil {
+		if errors.Is(err, parser.ErrKeyReferenced) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/schemas"
+)
+
+func (h *Handlers) GetOptions(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, bindService.GetOptions())
+}
+
+func (h *Handlers) PatchOptions(c *gin.Context) {
+	bindService := h.bindServer(c)
+	if bindService == nil {
+		return
+	}
+
+	var data schemas.OptionsData
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := bindService.UpdateOptions(&data)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
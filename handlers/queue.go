@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/backend"
+)
+
+// Returns the backend to queue the record change of the request, or nil to wait until it is applied.
+// Changes are only queued if the `wait` query parameter is false, which is refused if the backend does
+// not batch changes. Returns false if the request was refused and the response written.
+func (h *Handlers) batcher(c *gin.Context) (backend.Batcher, bool) {
+	if c.Query("wait") != "false" {
+		return nil, true
+	}
+
+	batcher, ok := h.requestBackend(c).(backend.Batcher)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "changes are not queued by this backend, wait=false is not supported"})
+		return nil, false
+	}
+
+	return batcher, true
+}
+
+// Responds with the error, or with the queued change to poll. Returns false if nothing was written,
+// when the change was applied right away and the usual response follows.
+func respondQueued(c *gin.Context, code int, queued *schemas.QueuedChange, err error) bool {
+	if err != nil {
+		errorResponse(c, code, err)
+		return true
+	}
+
+	if queued != nil {
+		c.Header("Location", queuePath(c, queued.Id))
+		c.JSON(http.StatusAccepted, queued)
+		return true
+	}
+
+	return false
+}
+
+// Returns the path to poll a queued change, under the same group as the zone route of the request,
+// like /api/queue/:id for /api/zones/:origin/records.
+func queuePath(c *gin.Context, id string) string {
+	group := c.Request.URL.Path
+	if i := strings.Index(group, "/zones/"); i >= 0 {
+		group = group[:i]
+	}
+
+	return group + "/queue/" + id
+}
+
+func (h *Handlers) GetQueuedChange(c *gin.Context) {
+	server := h.server(c)
+	if server == nil {
+		return
+	}
+
+	batcher, ok := server.(backend.Batcher)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "changes are not queued by this backend"})
+		return
+	}
+
+	queued, err := batcher.GetQueuedChange(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, queued)
+}
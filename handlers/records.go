@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/svex99/bind-api/services/bind"
 	"github.com/svex99/bind-api/services/bind/parser"
 )
 
@@ -65,7 +64,7 @@ func getRecord(c *gin.Context) (parser.Record, error) {
 	return record, nil
 }
 
-func PostRecord(c *gin.Context) {
+func (h *Handlers) PostRecord(c *gin.Context) {
 	origin := c.Param("origin")
 
 	record, err := getRecord(c)
@@ -73,15 +72,27 @@ func PostRecord(c *gin.Context) {
 		return
 	}
 
-	if err := bind.Service.AddRecord(origin, record); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	batcher, ok := h.batcher(c)
+	if !ok {
+		return
+	}
+
+	dnsBackend := h.changeBackend(c)
+
+	if batcher != nil {
+		queued, err := batcher.QueueAddRecord(origin, record)
+		if respondQueued(c, http.StatusBadRequest, queued, err) {
+			return
+		}
+	} else if err := dnsBackend.AddRecord(origin, record); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, record)
+	respondChange(c, http.StatusCreated, record, dnsBackend)
 }
 
-func PatchRecord(c *gin.Context) {
+func (h *Handlers) PatchRecord(c *gin.Context) {
 	origin := c.Param("origin")
 	target := c.Param("target") + "\n"
 
@@ -92,15 +103,27 @@ func PatchRecord(c *gin.Context) {
 		return
 	}
 
-	if err := bind.Service.UpdateRecord(origin, target, record); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	batcher, ok := h.batcher(c)
+	if !ok {
+		return
+	}
+
+	dnsBackend := h.changeBackend(c)
+
+	if batcher != nil {
+		queued, err := batcher.QueueUpdateRecord(origin, target, record)
+		if respondQueued(c, http.StatusBadRequest, queued, err) {
+			return
+		}
+	} else if err := dnsBackend.UpdateRecord(origin, target, record); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, record)
+	respondChange(c, http.StatusOK, record, dnsBackend)
 }
 
-func DeleteRecord(c *gin.Context) {
+func (h *Handlers) DeleteRecord(c *gin.Context) {
 	origin := c.Param("origin")
 
 	record, err := getRecord(c)
@@ -108,10 +131,22 @@ func DeleteRecord(c *gin.Context) {
 		return
 	}
 
-	if err := bind.Service.DeleteRecord(origin, record); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	batcher, ok := h.batcher(c)
+	if !ok {
+		return
+	}
+
+	dnsBackend := h.changeBackend(c)
+
+	if batcher != nil {
+		queued, err := batcher.QueueDeleteRecord(origin, record)
+		if respondQueued(c, http.StatusNotFound, queued, err) {
+			return
+		}
+	} else if err := dnsBackend.DeleteRecord(origin, record); err != nil {
+		errorResponse(c, http.StatusNotFound, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "record deleted"})
+	respondChange(c, http.StatusOK, gin.H{"message": "record deleted"}, dnsBackend)
 }
@@ -1,42 +1,30 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/svex99/bind-api/schemas"
-	"github.com/svex99/bind-api/services/bind"
-	"github.com/svex99/bind-api/services/bind/parser"
 )
 
-func ListZones(c *gin.Context) {
-	bind.Service.Mutex.Lock()
-	defer bind.Service.Mutex.Unlock()
-
-	zones := []*parser.ZoneConf{}
-
-	for _, zone := range bind.Service.Zones {
-		zones = append(zones, zone)
-	}
-
-	c.JSON(http.StatusOK, zones)
+func (h *Handlers) ListZones(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Backend.ListZones())
 }
 
-func GetZone(c *gin.Context) {
+func (h *Handlers) GetZone(c *gin.Context) {
 	origin := c.Param("origin")
 
-	zConf, ok := bind.Service.Zones[origin]
-	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("zone %s does not exist", origin)})
+	zConf, err := h.Backend.GetZone(origin)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, zConf)
 }
 
-func NewZone(c *gin.Context) {
+func (h *Handlers) NewZone(c *gin.Context) {
 	var data schemas.ZoneData
 
 	if err := c.ShouldBindJSON(&data); err != nil {
@@ -44,16 +32,18 @@ func NewZone(c *gin.Context) {
 		return
 	}
 
-	zConf, err := bind.Service.CreateZone(&data)
+	dnsBackend := h.changeBackend(c)
+
+	zConf, err := dnsBackend.CreateZone(&data)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, zConf)
+	respondChange(c, http.StatusCreated, zConf, dnsBackend)
 }
 
-func PatchZone(c *gin.Context) {
+func (h *Handlers) PatchZone(c *gin.Context) {
 	var data schemas.ZoneData
 
 	if err := c.ShouldBindJSON(&data); err != nil {
@@ -61,22 +51,37 @@ func PatchZone(c *gin.Context) {
 		return
 	}
 
-	dConf, err := bind.Service.UpdateZone(data.Origin, &data)
+	dnsBackend := h.changeBackend(c)
+
+	dConf, err := dnsBackend.UpdateZone(data.Origin, &data)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, dConf)
+	respondChange(c, http.StatusOK, dConf, dnsBackend)
 }
 
-func DeleteZone(c *gin.Context) {
+func (h *Handlers) DeleteZone(c *gin.Context) {
 	origin := c.Param("origin")
 
-	if err := bind.Service.DeleteZone(origin); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	dnsBackend := h.changeBackend(c)
+
+	if err := dnsBackend.DeleteZone(origin); err != nil {
+		errorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondChange(c, http.StatusNoContent, gin.H{}, dnsBackend)
+}
+
+// Returns the issues found in the records of the zone.
+func (h *Handlers) ValidateZone(c *gin.Context) {
+	zConf, err := h.Backend.GetZone(c.Param("origin"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusNoContent, gin.H{})
+	c.JSON(http.StatusOK, gin.H{"issues": zConf.Validate()})
 }
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/services/bind"
+)
+
+// Runner that records the commands instead of running them. The content of the files given to
+// named-checkzone and named-checkconf is kept, as BIND would read them.
+type RecordingRunner struct {
+	// Directory where the paths seen by BIND are
+	Root string
+
+	mutex    sync.Mutex
+	commands []string
+	checked  []string
+}
+
+func (r *RecordingRunner) Run(command ...string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if strings.HasPrefix(command[0], "named-check") {
+		content, err := os.ReadFile(filepath.Join(r.Root, command[len(command)-1]))
+		if err != nil {
+			return "", err
+		}
+		r.checked = append(r.checked, string(content))
+	}
+
+	r.commands = append(r.commands, strings.Join(command, " "))
+	return "", nil
+}
+
+// Returns the commands run so far.
+func (r *RecordingRunner) Commands() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.commands...)
+}
+
+// Returns the number of times the command was run.
+func (r *RecordingRunner) Count(command string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, c := range r.commands {
+		if c == command {
+			count++
+		}
+	}
+	return count
+}
+
+// Returns the content of the files checked so far.
+func (r *RecordingRunner) Checked() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.checked...)
+}
+
+// Creates the service of a BIND server rooted in a temporary directory, with the files written at their
+// path as seen by BIND, like /etc/bind/named.conf.local or /var/lib/bind/db.example.com.
+// Checks, the history and the watcher are disabled, `configure` can change the settings before the
+// service is initialized. Commands are recorded by the returned runner instead of run.
+func NewBindService(t *testing.T, files map[string]string, configure func(bindSetting *setting.BindSetting)) (*bind.BindService, *RecordingRunner) {
+	root := t.TempDir()
+
+	bindSetting := &setting.BindSetting{
+		ConfPath:      root + "/etc/bind/",
+		LibPath:       root + "/var/lib/bind/",
+		PathMap:       []string{"/=" + root + "/"},
+		Runner:        "local",
+		SkipChecks:    true,
+		HistorySize:   -1,
+		WatchInterval: -1,
+	}
+	if configure != nil {
+		configure(bindSetting)
+	}
+
+	for _, dir := range []string{bindSetting.ConfPath, bindSetting.LibPath} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for filename, content := range files {
+		filename = filepath.Join(root, filename)
+
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bindService := bind.New(bindSetting)
+	bindService.Init()
+
+	runner := &RecordingRunner{Root: root}
+	bindService.Runner = runner
+
+	return bindService, runner
+}
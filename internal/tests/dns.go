@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Key the stub DNS server shares with its clients.
+const (
+	DdnsKey    = "ddns"
+	DdnsSecret = "c2VjcmV0IHNoYXJlZCB3aXRoIHRoZSBzdHViIHNlcnZlcg=="
+)
+
+func MustRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rr
+}
+
+// Serves the zone of the records over TCP, the SOA record first, applying updates signed with the
+// ddns key and answering signed transfers. Updated SOA records and records already served are
+// replaced, along with their TTL, records of class NONE are removed. The returned function gives the
+// records served.
+func StubDnsServer(t *testing.T, records []dns.RR) (string, func() []dns.RR) {
+	var mutex sync.Mutex
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		response := new(dns.Msg)
+		response.SetReply(r)
+
+		if w.TsigStatus() != nil {
+			response.Rcode = dns.RcodeNotAuth
+			w.WriteMsg(response)
+			return
+		}
+
+		tsig := r.IsTsig()
+
+		if r.Opcode == dns.OpcodeUpdate {
+			for _, rr := range r.Ns {
+				if _, ok := rr.(*dns.SOA); ok {
+					records[0] = rr
+					continue
+				}
+
+				if rr.Header().Class == dns.ClassNONE {
+					removed := dns.Copy(rr)
+					removed.Header().Class = dns.ClassINET
+
+					for i, record := range records {
+						if dns.IsDuplicate(record, removed) {
+							records = append(records[:i], records[i+1:]...)
+							break
+						}
+					}
+					continue
+				}
+
+				replaced := false
+				for i, record := range records {
+					if dns.IsDuplicate(record, rr) {
+						records[i] = rr
+						replaced = true
+					}
+				}
+				if !replaced {
+					records = append(records, rr)
+				}
+			}
+
+			response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+			w.WriteMsg(response)
+			return
+		}
+
+		envelopes := make(chan *dns.Envelope, 1)
+		envelopes <- &dns.Envelope{RR: append(append([]dns.RR{}, records...), records[0])}
+		close(envelopes)
+
+		response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+		transfer := &dns.Transfer{TsigSecret: map[string]string{DdnsKey + ".": DdnsSecret}}
+		transfer.Out(w, r, envelopes)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &dns.Server{
+		Listener:   listener,
+		Handler:    handler,
+		TsigSecret: map[string]string{DdnsKey + ".": DdnsSecret},
+		// The default function rejects updates
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	served := func() []dns.RR {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return append([]dns.RR{}, records...)
+	}
+
+	return listener.Addr().String(), served
+}
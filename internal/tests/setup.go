@@ -70,12 +70,12 @@ func CreateZonesBulk(amount int) error {
 			},
 		}
 
-		if _, err := dc.WriteToDisk(setting.Bind.LibPath + "db." + zoneName); err != nil {
+		if err := os.WriteFile(setting.Bind.LibPath+"db."+zoneName, []byte(dc.String()), 0644); err != nil {
 			return err
 		}
 	}
 
-	if _, err := bindConf.WriteToDisk(setting.Bind.ConfPath + "named.conf.local"); err != nil {
+	if err := os.WriteFile(setting.Bind.ConfPath+"named.conf.local", []byte(bindConf.String()), 0644); err != nil {
 		return err
 	}
 
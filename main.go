@@ -5,15 +5,25 @@ import (
 	"os"
 
 	"github.com/svex99/bind-api/api"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/services/backend"
 	"github.com/svex99/bind-api/services/bind"
+	"github.com/svex99/bind-api/services/fleet"
 )
 
 func main() {
 	log.Println("Starting BIND API...")
 
-	bind.Service.Init()
+	setting.Setup()
 
-	router := api.SetupRouter(true)
+	var dnsBackend backend.Backend = bind.Service
+	if len(setting.Servers) > 0 {
+		dnsBackend = setupFleet()
+	} else {
+		bind.Service.Init()
+	}
+
+	router := api.SetupRouter(true, dnsBackend)
 
 	address := ":2020"
 	if envPort := os.Getenv("PORT"); envPort != "" {
@@ -24,3 +34,27 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// Starts the service of each server in the settings and reports the zones that drifted apart.
+func setupFleet() *fleet.Fleet {
+	servers := []*fleet.Server{}
+	for _, server := range setting.Servers {
+		log.Printf("Loading BIND server %s\n", server.Name)
+
+		bindService := bind.New(server.Bind)
+		bindService.Init()
+
+		servers = append(servers, &fleet.Server{Name: server.Name, Backend: bindService})
+	}
+
+	fleetBackend, err := fleet.New(servers...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, drift := range fleetBackend.Drift() {
+		log.Printf("Zone %s differs between the servers holding it\n", drift.Origin)
+	}
+
+	return fleetBackend
+}
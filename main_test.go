@@ -14,11 +14,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/svex99/bind-api/api"
 	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
 	"github.com/svex99/bind-api/schemas"
 	"github.com/svex99/bind-api/services/bind"
 	"github.com/svex99/bind-api/services/bind/parser"
 )
 
+func TestMain(m *testing.M) {
+	setting.Setup()
+
+	os.Exit(m.Run())
+}
+
 func BenchmarkAddZoneLatency(b *testing.B) {
 	amounts := []int{0, 250, 500, 1000, 2000, 4000}
 
@@ -45,7 +52,7 @@ func BenchmarkAddZoneLatency(b *testing.B) {
 					b.Fatal(err)
 				}
 
-				router := api.SetupRouter(false)
+				router := api.SetupRouter(false, bind.Service)
 				bind.Service.Init()
 
 				w := httptest.NewRecorder()
@@ -79,7 +86,7 @@ func BenchmarkGetZonesLatency(b *testing.B) {
 			b.Fatal(err)
 		}
 
-		router := api.SetupRouter(false)
+		router := api.SetupRouter(false, bind.Service)
 		bind.Service.Init()
 
 		b.Run(fmt.Sprintf("GetZonesLatencyWith%d", amount), func(b *testing.B) {
@@ -138,7 +145,7 @@ func TestAPIFlow(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	router := api.SetupRouter(false)
+	router := api.SetupRouter(false, bind.Service)
 	bind.Service.Init()
 
 	t.Run("TestAddZone", func(t *testing.T) {
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/services/backend"
+)
+
+// Key of the gin context holding the view of the backend the request changes, see RecordChanges.
+const BackendKey = "backend"
+
+// Records the changes of each request that may modify the server. The request is served by a view
+// of the backend, set in the gin context under BackendKey, that keeps the files it changes, so the
+// changes of a request are never recorded along with the ones of another and requests are not
+// serialized. The actor is read from the X-Actor header and the message from the X-Change-Message
+// header. The response is held until the change is recorded, an error recording it is returned
+// instead, even though the change was applied.
+func RecordChanges(recorder backend.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		view := recorder.WithChanges()
+		c.Set(BackendKey, view)
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+
+		endpoint := method + " " + c.Request.URL.Path
+
+		change := gitstore.Change{
+			Actor:    c.GetHeader("X-Actor"),
+			Endpoint: endpoint,
+			Message:  c.GetHeader("X-Change-Message"),
+		}
+		if change.Actor == "" {
+			change.Actor = c.ClientIP()
+		}
+		if change.Message == "" {
+			change.Message = endpoint
+		}
+
+		if err := view.RecordChange(change); err != nil {
+			log.Printf("Error recording change of %s: %s\n", endpoint, err)
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "the change was applied but not recorded: " + err.Error()})
+			return
+		}
+
+		writer.flush()
+	}
+}
+
+// Response writer that holds the response until it is flushed.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// Writes the held response.
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,108 @@
+// Client of RFC 2136 dynamic updates and zone transfers, authenticated with a TSIG key.
+package ddns
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+type Client struct {
+	// Address of the DNS server, like 127.0.0.1:53
+	Address   string
+	KeyName   string
+	Algorithm string
+	// Base64 encoded secret, as it appears in the `key` statement
+	Secret  string
+	Timeout time.Duration
+}
+
+func NewClient(address, keyName, algorithm, secret string) *Client {
+	return &Client{
+		Address:   address,
+		KeyName:   dns.Fqdn(keyName),
+		Algorithm: dns.Fqdn(strings.ToLower(algorithm)),
+		Secret:    secret,
+		Timeout:   10 * time.Second,
+	}
+}
+
+// Error returned when the server refuses an update.
+type UpdateError struct {
+	Zone  string
+	Rcode int
+}
+
+func (e *UpdateError) Error() string {
+	return fmt.Sprintf("update of zone %s failed: %s", e.Zone, dns.RcodeToString[e.Rcode])
+}
+
+func (c *Client) tsigSecret() map[string]string {
+	return map[string]string{c.KeyName: c.Secret}
+}
+
+// Sends a single update message that removes and inserts the given records atomically.
+func (c *Client) Update(zone string, remove, insert []dns.RR) error {
+	msg := new(dns.Msg)
+	msg.SetUpdate(dns.Fqdn(zone))
+
+	if len(remove) > 0 {
+		msg.Remove(remove)
+	}
+	if len(insert) > 0 {
+		msg.Insert(insert)
+	}
+
+	msg.SetTsig(c.KeyName, c.Algorithm, 300, time.Now().Unix())
+
+	client := &dns.Client{Net: "tcp", TsigSecret: c.tsigSecret(), Timeout: c.Timeout}
+
+	response, _, err := client.Exchange(msg, c.Address)
+	if err != nil {
+		return err
+	}
+
+	if response.Rcode != dns.RcodeSuccess {
+		return &UpdateError{Zone: zone, Rcode: response.Rcode}
+	}
+
+	return nil
+}
+
+// Returns the records of the zone with a full zone transfer, the SOA record is the first one.
+func (c *Client) Transfer(zone string) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(zone))
+	msg.SetTsig(c.KeyName, c.Algorithm, 300, time.Now().Unix())
+
+	transfer := &dns.Transfer{
+		TsigSecret:   c.tsigSecret(),
+		DialTimeout:  c.Timeout,
+		ReadTimeout:  c.Timeout,
+		WriteTimeout: c.Timeout,
+	}
+
+	envelopes, err := transfer.In(msg, c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []dns.RR{}
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("transfer of zone %s failed: %w", zone, envelope.Error)
+		}
+		records = append(records, envelope.RR...)
+	}
+
+	// The SOA record is repeated at the end of the transfer
+	if len(records) > 1 {
+		if _, ok := records[len(records)-1].(*dns.SOA); ok {
+			records = records[:len(records)-1]
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,42 @@
+package ddns_test
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/ddns"
+)
+
+func TestClient(t *testing.T) {
+	address, _ := tests.StubDnsServer(t, []dns.RR{
+		tests.MustRR(t, "example.com. 3600 IN SOA ns1.example.com. admin.example.com. 1 3600 600 86400 60"),
+		tests.MustRR(t, "example.com. 3600 IN NS ns1.example.com."),
+		tests.MustRR(t, "ns1.example.com. 3600 IN A 10.0.0.1"),
+	})
+
+	client := ddns.NewClient(address, tests.DdnsKey, "hmac-sha256", tests.DdnsSecret)
+
+	err := client.Update("example.com", nil, []dns.RR{tests.MustRR(t, "www.example.com. 3600 IN A 10.0.0.2")})
+	assert.Nil(t, err)
+
+	err = client.Update(
+		"example.com",
+		[]dns.RR{tests.MustRR(t, "www.example.com. 3600 IN A 10.0.0.2")},
+		[]dns.RR{tests.MustRR(t, "www.example.com. 3600 IN A 10.0.0.3")},
+	)
+	assert.Nil(t, err)
+
+	records, err := client.Transfer("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, records, 4)
+	assert.IsType(t, &dns.SOA{}, records[0])
+	assert.Equal(t, "www.example.com.\t3600\tIN\tA\t10.0.0.3", records[3].String())
+
+	wrongKey := ddns.NewClient(address, tests.DdnsKey, "hmac-sha256", "d3Jvbmc=")
+	assert.NotNil(t, wrongKey.Update("example.com", nil, []dns.RR{tests.MustRR(t, "ftp.example.com. 3600 IN A 10.0.0.4")}))
+}
@@ -0,0 +1,235 @@
+package dnssec
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DS digest types, from the IANA "Delegation Signer (DS) Resource Record Digest Algorithms" registry.
+const (
+	DigestSHA1   uint8 = 1
+	DigestSHA256 uint8 = 2
+	DigestSHA384 uint8 = 4
+)
+
+var DigestNames = map[uint8]string{
+	DigestSHA1:   "SHA-1",
+	DigestSHA256: "SHA-256",
+	DigestSHA384: "SHA-384",
+}
+
+// Returns the digest type with the given name, like SHA-256.
+func DigestType(name string) (uint8, error) {
+	for digestType, digestName := range DigestNames {
+		if strings.EqualFold(name, digestName) {
+			return digestType, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported digest type %s", name)
+}
+
+// DNSKEY flag set on key signing keys.
+const flagSEP = 1
+
+type DNSKEY struct {
+	Owner     string
+	Flags     uint16
+	Protocol  uint8
+	Algorithm uint8
+	PublicKey []byte
+}
+
+type DS struct {
+	Owner      string `json:"owner"`
+	KeyTag     uint16 `json:"keyTag"`
+	Algorithm  uint8  `json:"algorithm"`
+	DigestType uint8  `json:"digestType"`
+	Digest     string `json:"digest"`
+}
+
+// Parses the DNSKEY record of a `K<zone>.+<alg>+<tag>.key` file as written by BIND.
+func ParseKeyFile(content string) (*DNSKEY, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		index := -1
+		for i, field := range fields {
+			if strings.EqualFold(field, "DNSKEY") {
+				index = i
+				break
+			}
+		}
+
+		if index < 1 || len(fields) < index+5 {
+			return nil, fmt.Errorf("invalid DNSKEY record '%s'", line)
+		}
+
+		flags, err := strconv.ParseUint(fields[index+1], 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		protocol, err := strconv.ParseUint(fields[index+2], 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, err := strconv.ParseUint(fields[index+3], 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := base64.StdEncoding.DecodeString(strings.Join(fields[index+4:], ""))
+		if err != nil {
+			return nil, err
+		}
+
+		return &DNSKEY{
+			Owner:     fields[0],
+			Flags:     uint16(flags),
+			Protocol:  uint8(protocol),
+			Algorithm: uint8(algorithm),
+			PublicKey: publicKey,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no DNSKEY record found")
+}
+
+// Returns true if the key has the SEP flag, so its DS must be published in the parent zone.
+func (k *DNSKEY) IsKSK() bool {
+	return k.Flags&flagSEP != 0
+}
+
+func (k *DNSKEY) rdata() []byte {
+	rdata := make([]byte, 4, 4+len(k.PublicKey))
+	binary.BigEndian.PutUint16(rdata, k.Flags)
+	rdata[2] = k.Protocol
+	rdata[3] = k.Algorithm
+	return append(rdata, k.PublicKey...)
+}
+
+// Computes the key tag as defined in RFC 4034, Appendix B.
+func (k *DNSKEY) KeyTag() uint16 {
+	var ac uint32
+
+	for i, b := range k.rdata() {
+		if i&1 == 1 {
+			ac += uint32(b)
+		} else {
+			ac += uint32(b) << 8
+		}
+	}
+
+	ac += ac >> 16 & 0xFFFF
+
+	return uint16(ac & 0xFFFF)
+}
+
+// Builds the DS record of the key with the given digest type, as defined in RFC 4034 section 5.1.4.
+func (k *DNSKEY) DS(digestType uint8) (*DS, error) {
+	owner, err := wireName(k.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	data := append(owner, k.rdata()...)
+
+	var digest []byte
+
+	switch digestType {
+	case DigestSHA1:
+		sum := sha1.Sum(data)
+		digest = sum[:]
+	case DigestSHA256:
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case DigestSHA384:
+		sum := sha512.Sum384(data)
+		digest = sum[:]
+	default:
+		return nil, fmt.Errorf("unsupported digest type %d", digestType)
+	}
+
+	return &DS{
+		Owner:      k.Owner,
+		KeyTag:     k.KeyTag(),
+		Algorithm:  k.Algorithm,
+		DigestType: digestType,
+		Digest:     strings.ToUpper(hex.EncodeToString(digest)),
+	}, nil
+}
+
+// Parses a DS record like the ones printed by `dnssec-dsfromkey`.
+func ParseDS(line string) (*DS, error) {
+	fields := strings.Fields(line)
+
+	index := -1
+	for i, field := range fields {
+		if strings.EqualFold(field, "DS") {
+			index = i
+			break
+		}
+	}
+
+	if index < 1 || len(fields) < index+5 {
+		return nil, fmt.Errorf("invalid DS record '%s'", line)
+	}
+
+	keyTag, err := strconv.ParseUint(fields[index+1], 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := strconv.ParseUint(fields[index+2], 10, 8)
+	if err != nil {
+		return nil, err
+	}
+	digestType, err := strconv.ParseUint(fields[index+3], 10, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DS{
+		Owner:      fields[0],
+		KeyTag:     uint16(keyTag),
+		Algorithm:  uint8(algorithm),
+		DigestType: uint8(digestType),
+		Digest:     strings.ToUpper(strings.Join(fields[index+4:], "")),
+	}, nil
+}
+
+func (ds *DS) String() string {
+	return fmt.Sprintf("%s IN DS %d %d %d %s", ds.Owner, ds.KeyTag, ds.Algorithm, ds.DigestType, ds.Digest)
+}
+
+// Encodes a domain name in canonical wire format (RFC 4034 section 6.2).
+func wireName(name string) ([]byte, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	wire := []byte{}
+
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid label in domain name %s", name)
+			}
+			wire = append(wire, byte(len(label)))
+			wire = append(wire, label...)
+		}
+	}
+
+	return append(wire, 0), nil
+}
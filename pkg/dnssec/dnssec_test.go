@@ -0,0 +1,61 @@
+package dnssec_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/pkg/dnssec"
+)
+
+// Key and digests from the examples of RFC 4034 section 5.4 and RFC 4509 section 2.2.1
+const keyFile = `; This is a zone-signing key, keyid 60485, for dskey.example.com.
+dskey.example.com. 86400 IN DNSKEY 256 3 5 AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvxegXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9XzcnOf+EPbtG9DMBmADjFDc2w/rljwvFw==
+`
+
+func TestDS(t *testing.T) {
+	key, err := dnssec.ParseKeyFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint16(60485), key.KeyTag())
+	assert.False(t, key.IsKSK())
+
+	ds, err := key.DS(dnssec.DigestSHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "dskey.example.com. IN DS 60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118", ds.String())
+
+	ds, err = key.DS(dnssec.DigestSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A", ds.Digest)
+
+	parsed, err := dnssec.ParseDS(ds.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ds, parsed)
+}
+
+func TestParentAction(t *testing.T) {
+	state := dnssec.ParseStateFile(`; This is the state of key 12345, for example.com.
+Algorithm: 13
+KSK: yes
+ZSK: yes
+GoalState: omnipresent
+DSState: rumoured
+`)
+
+	assert.Equal(t, "13", state["Algorithm"])
+	assert.Equal(t, dnssec.ParentPublish, state.ParentAction())
+
+	state["GoalState"] = "hidden"
+	state["DSState"] = "omnipresent"
+	assert.Equal(t, dnssec.ParentWithdraw, state.ParentAction())
+
+	state["DSState"] = "hidden"
+	assert.Equal(t, "", state.ParentAction())
+}
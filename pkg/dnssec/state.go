@@ -0,0 +1,53 @@
+package dnssec
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Actions needed in the parent zone to continue a rollover.
+const (
+	ParentPublish  = "publish"
+	ParentWithdraw = "withdraw"
+)
+
+// Metadata of a `K<zone>.+<alg>+<tag>.state` file, written by BIND for keys managed by a dnssec-policy.
+type KeyState map[string]string
+
+func ParseStateFile(content string) KeyState {
+	state := KeyState{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			state[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	return state
+}
+
+// Returns the action the parent zone must take with the DS of the key, if any.
+// A key that is being introduced waits for its DS to be published, and a key that is
+// being retired waits for its DS to be withdrawn.
+func (ks KeyState) ParentAction() string {
+	if ks["KSK"] != "yes" {
+		return ""
+	}
+
+	goal, ds := ks["GoalState"], ks["DSState"]
+
+	switch {
+	case goal == "omnipresent" && (ds == "hidden" || ds == "rumoured"):
+		return ParentPublish
+	case goal == "hidden" && (ds == "omnipresent" || ds == "unretentive"):
+		return ParentWithdraw
+	}
+
+	return ""
+}
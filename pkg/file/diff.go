@@ -0,0 +1,185 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unchanged lines shown around each change of a diff
+const diffContext = 3
+
+// Line of an edit script: ' ' kept, '-' deleted or '+' inserted.
+type edit struct {
+	op   byte
+	line string
+}
+
+// Returns the line differences between two texts in unified diff format, empty if they are equal.
+func Diff(fromName, toName string, from, to []byte) string {
+	edits := editScript(splitLines(string(from)), splitLines(string(to)))
+
+	// Positions of the changed lines in the edit script
+	changes := []int{}
+	for i, e := range edits {
+		if e.op != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(changes); {
+		// Changes closer than twice the context share a hunk
+		end := start
+		for end+1 < len(changes) && changes[end+1]-changes[end] <= 2*diffContext {
+			end++
+		}
+
+		first := changes[start] - diffContext
+		if first < 0 {
+			first = 0
+		}
+		last := changes[end] + diffContext
+		if last >= len(edits) {
+			last = len(edits) - 1
+		}
+
+		writeHunk(&diff, edits, first, last)
+
+		start = end + 1
+	}
+
+	return diff.String()
+}
+
+func writeHunk(diff *strings.Builder, edits []edit, first, last int) {
+	fromStart, toStart := 1, 1
+	for _, e := range edits[:first] {
+		if e.op != '+' {
+			fromStart++
+		}
+		if e.op != '-' {
+			toStart++
+		}
+	}
+
+	fromCount, toCount := 0, 0
+	for _, e := range edits[first : last+1] {
+		if e.op != '+' {
+			fromCount++
+		}
+		if e.op != '-' {
+			toCount++
+		}
+	}
+
+	// Empty ranges refer to the line before them
+	if fromCount == 0 {
+		fromStart--
+	}
+	if toCount == 0 {
+		toStart--
+	}
+
+	fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+
+	for _, e := range edits[first : last+1] {
+		diff.WriteByte(e.op)
+		diff.WriteString(e.line)
+		diff.WriteByte('\n')
+	}
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// Returns the shortest edit script that turns `a` into `b`, using the Myers algorithm.
+func editScript(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+
+	// Furthest x reached on each diagonal k = x - y, indexed by k + offset
+	v := make([]int, 2*offset+1)
+	// Copies of v before each round, to walk the path back
+	trace := [][]int{}
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int{}, v...))
+
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+
+		if done {
+			break
+		}
+	}
+
+	edits := []edit{}
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{'+', b[y-1]})
+				y--
+			} else {
+				edits = append(edits, edit{'-', a[x-1]})
+				x--
+			}
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
@@ -2,8 +2,8 @@ package file
 
 import (
 	"io/ioutil"
-	"log"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -45,19 +45,61 @@ func AddContent(filePath, content string) error {
 	return nil
 }
 
-// Makes a backup (.bak file) of a plain text file
-// Returns a function that rollbacks the backup file
-func MakeBackup(filename string) func() {
-	backup := filename + ".bak"
-	bak_err := os.Rename(filename, backup)
-	rollback := func() {
-		log.Println("> Rollback " + filename)
-
-		if bak_err == nil {
-			if err := os.Rename(backup, filename); err != nil {
-				panic(err)
-			}
-		}
+// Replaces the content of a file atomically. The content is written to a temporary file in the same
+// directory, flushed to disk and renamed over the file, so the file always has either the old or the
+// new content, even after a crash. The permissions of the replaced file are kept.
+func WriteAtomic(filename string, content []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	return writeAtomic(filename, content, perm)
+}
+
+func writeAtomic(filename string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Does nothing once the temporary file is renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
 	}
-	return rollback
+
+	return syncDir(dir)
+}
+
+// Flushes the entries of a directory to disk, so renames and removals in it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
 }
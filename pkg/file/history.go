@@ -0,0 +1,132 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var ErrVersionNotFound = errors.New("version not found")
+
+// Timestamped versions of files, stored in a directory per file under the same path as the file,
+// so files with the same name in different directories keep their own versions.
+type History struct {
+	Dir string
+	// Versions kept of each file, the oldest ones are removed
+	Limit int
+}
+
+// Version of a file, identified by the time it was saved.
+type Version struct {
+	Id   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// Ids of the versions are their UTC time, so they sort in chronological order.
+const versionFormat = "20060102T150405.000000000Z"
+
+// Returns the directory with the versions of the file.
+func (h *History) dir(filename string) (string, error) {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(h.Dir, filename), nil
+}
+
+// Saves the content as the newest version of the file, removing the versions over the limit.
+func (h *History) Save(filename string, content []byte) (*Version, error) {
+	dir, err := h.dir(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := now.Format(versionFormat)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, id)); os.IsNotExist(err) {
+			break
+		}
+		now = now.Add(time.Nanosecond)
+		id = now.Format(versionFormat)
+	}
+
+	if err := WriteAtomic(filepath.Join(dir, id), content); err != nil {
+		return nil, err
+	}
+
+	versions, err := h.List(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := h.Limit; i < len(versions); i++ {
+		if err := os.Remove(filepath.Join(dir, versions[i].Id)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Version{Id: id, Time: now, Size: int64(len(content))}, nil
+}
+
+// Returns the versions of the file, the newest first.
+func (h *History) List(filename string) ([]*Version, error) {
+	dir, err := h.dir(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*Version{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := []*Version{}
+	for _, entry := range entries {
+		versionTime, err := time.Parse(versionFormat, entry.Name())
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, &Version{Id: entry.Name(), Time: versionTime, Size: info.Size()})
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Id > versions[j].Id })
+
+	return versions, nil
+}
+
+// Returns the content of a version of the file.
+func (h *History) Read(filename, id string) ([]byte, error) {
+	// Only ids in the version format are accepted, so they can not point outside the history
+	if _, err := time.Parse(versionFormat, id); err != nil {
+		return nil, ErrVersionNotFound
+	}
+
+	dir, err := h.dir(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, id))
+	if os.IsNotExist(err) {
+		return nil, ErrVersionNotFound
+	}
+
+	return content, err
+}
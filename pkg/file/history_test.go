@@ -0,0 +1,81 @@
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+	history := &file.History{Dir: filepath.Join(dir, ".history"), Limit: 3}
+	zone := filepath.Join(dir, "db.example.com")
+
+	if err := os.WriteFile(zone, []byte("v0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Committed writes are saved, along with the content before the first one
+	for _, content := range []string{"v1", "v2", "v3"} {
+		tx, err := file.Begin(filepath.Join(dir, ".transactions"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.History = history
+
+		assert.Nil(t, tx.Write(zone, []byte(content)))
+		tx.Commit()
+	}
+
+	versions, err := history.List(zone)
+	assert.Nil(t, err)
+	assert.Len(t, versions, 3)
+
+	content, err := history.Read(zone, versions[0].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "v3", string(content))
+
+	content, err = history.Read(zone, versions[2].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", string(content))
+
+	_, err = history.Read(zone, "../../db.example.com")
+	assert.ErrorIs(t, err, file.ErrVersionNotFound)
+
+	versions, err = history.List(filepath.Join(dir, "db.example.org"))
+	assert.Nil(t, err)
+	assert.Empty(t, versions)
+
+	// Files with the same name in other directories have their own versions
+	versions, err = history.List(filepath.Join(dir, "zones", "db.example.com"))
+	assert.Nil(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	expected := `--- v1
++++ v2
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,3 +8,4 @@
+ h
+ i
+ j
++k
+`
+
+	assert.Equal(t, expected, file.Diff("v1", "v2", []byte(from), []byte(to)))
+	assert.Equal(t, "", file.Diff("v1", "v2", []byte(from), []byte(from)))
+	assert.Equal(t, "--- v1\n+++ v2\n@@ -0,0 +1,1 @@\n+a\n", file.Diff("v1", "v2", []byte(""), []byte("a\n")))
+}
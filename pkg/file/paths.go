@@ -0,0 +1,79 @@
+package file
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Maps path prefixes as seen by BIND to path prefixes as seen by the API,
+// like /var/lib/bind/ in the BIND container to data/bind/lib/ in the API container.
+type PathMap map[string]string
+
+// Parses a list of `bindPrefix=apiPrefix` entries.
+func ParsePathMap(entries []string) (PathMap, error) {
+	pathMap := PathMap{}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		bindPrefix, apiPrefix, ok := strings.Cut(entry, "=")
+		if !ok || bindPrefix == "" || apiPrefix == "" {
+			return nil, fmt.Errorf("invalid path map entry '%s', expected bindPath=apiPath", entry)
+		}
+
+		pathMap[bindPrefix] = apiPrefix
+	}
+
+	return pathMap, nil
+}
+
+// Returns the API path of a path as seen by BIND, using the longest matching prefix.
+// Relative paths are resolved from `dir`, like BIND does with the `directory` option.
+func (pm PathMap) Resolve(filePath, dir string) (string, error) {
+	if !path.IsAbs(filePath) {
+		if dir == "" {
+			return "", fmt.Errorf("cannot resolve relative path %s without a directory", filePath)
+		}
+		filePath = path.Join(dir, filePath)
+	}
+
+	match := ""
+	for bindPrefix := range pm {
+		if isPathPrefix(filePath, bindPrefix) && len(bindPrefix) > len(match) {
+			match = bindPrefix
+		}
+	}
+
+	if match == "" {
+		return "", fmt.Errorf("path %s is not mapped to any API path", filePath)
+	}
+
+	return path.Join(pm[match], strings.TrimPrefix(filePath, match)), nil
+}
+
+// Returns the path as seen by BIND of an API path, the inverse of Resolve.
+func (pm PathMap) BindPath(apiPath string) (string, error) {
+	apiPath = path.Clean(apiPath)
+
+	match := ""
+	for bindPrefix, apiPrefix := range pm {
+		if isPathPrefix(apiPath, path.Clean(apiPrefix)) && (match == "" || len(apiPrefix) > len(pm[match])) {
+			match = bindPrefix
+		}
+	}
+
+	if match == "" {
+		return "", fmt.Errorf("path %s is not mapped to any BIND path", apiPath)
+	}
+
+	return path.Join(match, strings.TrimPrefix(apiPath, path.Clean(pm[match]))), nil
+}
+
+func isPathPrefix(filePath, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return filePath == prefix || strings.HasPrefix(filePath, prefix+"/")
+}
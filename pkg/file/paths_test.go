@@ -0,0 +1,48 @@
+package file_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+func TestPathMap(t *testing.T) {
+	pathMap, err := file.ParsePathMap([]string{
+		"/etc/bind/=data/bind/conf/",
+		"/var/lib/bind=data/bind/lib",
+		"/var/lib/bind/signed/=/mnt/signed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := pathMap.Resolve("/var/lib/bind/db.example.com", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "data/bind/lib/db.example.com", resolved)
+
+	resolved, err = pathMap.Resolve("/var/lib/bind/signed/db.example.org", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "/mnt/signed/db.example.org", resolved)
+
+	resolved, err = pathMap.Resolve("zones/db.example.net", "/etc/bind")
+	assert.Nil(t, err)
+	assert.Equal(t, "data/bind/conf/zones/db.example.net", resolved)
+
+	_, err = pathMap.Resolve("/var/lib/binding/db.example.com", "")
+	assert.NotNil(t, err)
+
+	bindPath, err := pathMap.BindPath("data/bind/conf/named.conf.local.check")
+	assert.Nil(t, err)
+	assert.Equal(t, "/etc/bind/named.conf.local.check", bindPath)
+
+	bindPath, err = pathMap.BindPath("/mnt/signed/db.example.org")
+	assert.Nil(t, err)
+	assert.Equal(t, "/var/lib/bind/signed/db.example.org", bindPath)
+
+	_, err = pathMap.BindPath("data/other/db.example.com")
+	assert.NotNil(t, err)
+
+	_, err = file.ParsePathMap([]string{"/etc/bind/"})
+	assert.NotNil(t, err)
+}
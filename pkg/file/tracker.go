@@ -0,0 +1,161 @@
+package file
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Error returned when a file to write was changed by someone else since it was loaded.
+type ConflictError struct {
+	File string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("file %s was changed out of the API since it was loaded, try again once it is loaded", e.File)
+}
+
+// Contents of the files as last loaded or written by the API, to detect changes made by others.
+type Tracker struct {
+	mutex  *sync.Mutex
+	states map[string]*fileState
+	// Returns true for the files written by others as well, like the zone files dumped by BIND,
+	// which are neither checked for conflicts nor reported as changed. Every file is checked if nil.
+	Shared func(filename string) bool
+}
+
+type fileState struct {
+	// Hash of the loaded content, empty if the file did not exist
+	hash string
+	// Hash, size and modification time when the file was last seen, to skip hashing unchanged files
+	seen    string
+	size    int64
+	modTime time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{mutex: &sync.Mutex{}, states: map[string]*fileState{}}
+}
+
+// Reads the size and modification time of a file, zero if it does not exist.
+func statFile(filename string) (int64, time.Time, error) {
+	info, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return 0, time.Time{}, nil
+	} else if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return info.Size(), info.ModTime(), nil
+}
+
+// Reads the state of a file from disk.
+func readState(filename string) (*fileState, error) {
+	size, modTime, err := statFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &fileState{size: size, modTime: modTime}
+
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	state.hash = fmt.Sprintf("%x", sha256.Sum256(content))
+	state.seen = state.hash
+
+	return state, nil
+}
+
+func (t *Tracker) shared(filename string) bool {
+	return t.Shared != nil && t.Shared(filepath.Clean(filename))
+}
+
+// Records the current content of the file as loaded by the API.
+func (t *Tracker) Track(filename string) error {
+	if t.shared(filename) {
+		return nil
+	}
+
+	state, err := readState(filename)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.states[filepath.Clean(filename)] = state
+
+	return nil
+}
+
+// Returns a ConflictError if the file changed since it was tracked. Files that are not tracked
+// must not exist, so files created by others are not overwritten either.
+func (t *Tracker) Check(filename string) error {
+	if t.shared(filename) {
+		return nil
+	}
+
+	current, err := readState(filename)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	hash := ""
+	if state, ok := t.states[filepath.Clean(filename)]; ok {
+		hash = state.hash
+	}
+
+	if current.hash != hash {
+		return &ConflictError{File: filename}
+	}
+
+	return nil
+}
+
+// Returns the tracked files whose content changed since they were last seen.
+// A changed file keeps conflicting with writes until it is tracked again.
+func (t *Tracker) Changed() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	changed := []string{}
+
+	for filename, state := range t.states {
+		if t.shared(filename) {
+			continue
+		}
+
+		size, modTime, err := statFile(filename)
+		if err != nil || (size == state.size && modTime.Equal(state.modTime)) {
+			continue
+		}
+
+		current, err := readState(filename)
+		if err != nil {
+			continue
+		}
+
+		if current.hash != state.seen {
+			changed = append(changed, filename)
+		}
+
+		state.seen, state.size, state.modTime = current.hash, current.size, current.modTime
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
@@ -0,0 +1,262 @@
+package file
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Set of file changes that are committed or rolled back together. Each file is saved to a backup
+// (.bak file) before it is first replaced, and the changed files are listed in a journal until the
+// transaction ends. A transaction interrupted by a crash is rolled back by Recover.
+type Transaction struct {
+	// Where the written files are saved as new versions on commit, nil to keep no versions
+	History *History
+	// Called with the changed files once the transaction is committed
+	OnCommit func(files []string)
+	// Files are not changed if they were changed by others since tracked, and are tracked again on commit
+	Tracker *Tracker
+
+	journal string
+	changes []*change
+	done    bool
+}
+
+// File changed by a transaction.
+type change struct {
+	File string `json:"file"`
+	// Copy of the previous content, empty if the file did not exist
+	Backup string `json:"backup,omitempty"`
+
+	// Written content, nil if the file is removed
+	content []byte
+}
+
+// Journals are named tx-*.json in the transactions directory.
+const journalPattern = "tx-*.json"
+
+// Starts a transaction, with its journal in `dir`.
+func Begin(dir string) (*Transaction, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	journal, err := os.CreateTemp(dir, journalPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := journal.Close(); err != nil {
+		return nil, err
+	}
+
+	return &Transaction{journal: journal.Name(), changes: []*change{}}, nil
+}
+
+// Replaces the content of a file.
+func (tx *Transaction) Write(filename string, content []byte) error {
+	c, err := tx.save(filename)
+	if err != nil {
+		return err
+	}
+	c.content = content
+
+	return WriteAtomic(filename, content)
+}
+
+// Removes a file, if it exists.
+func (tx *Transaction) Remove(filename string) error {
+	c, err := tx.save(filename)
+	if err != nil {
+		return err
+	}
+	c.content = nil
+
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return syncDir(filepath.Dir(filename))
+}
+
+// Saves a backup of the file and adds it to the journal, unless the transaction changed it already.
+func (tx *Transaction) save(filename string) (*change, error) {
+	for _, c := range tx.changes {
+		if c.File == filename {
+			return c, nil
+		}
+	}
+
+	if tx.Tracker != nil {
+		if err := tx.Tracker.Check(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &change{File: filename}
+
+	info, err := os.Stat(filename)
+	if err == nil {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Backup = filename + ".bak"
+		if err := writeAtomic(c.Backup, content, info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	tx.changes = append(tx.changes, c)
+
+	content, err := json.Marshal(tx.changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, WriteAtomic(tx.journal, content)
+}
+
+// Keeps the changes and saves the written files in the history. The backups are left in place.
+func (tx *Transaction) Commit() {
+	if tx.done {
+		return
+	}
+	tx.done = true
+
+	if err := os.Remove(tx.journal); err != nil {
+		log.Printf("Error removing transaction journal %s: %s\n", tx.journal, err)
+	}
+
+	if tx.Tracker != nil {
+		for _, c := range tx.changes {
+			if err := tx.Tracker.Track(c.File); err != nil {
+				log.Printf("Error tracking %s: %s\n", c.File, err)
+			}
+		}
+	}
+
+	if tx.History != nil {
+		for _, c := range tx.changes {
+			if c.content == nil {
+				continue
+			}
+
+			if err := tx.saveVersions(c); err != nil {
+				log.Printf("Error saving version of %s: %s\n", c.File, err)
+			}
+		}
+	}
+
+	if tx.OnCommit != nil {
+		files := []string{}
+		for _, c := range tx.changes {
+			files = append(files, c.File)
+		}
+		tx.OnCommit(files)
+	}
+}
+
+// Saves the written content of a file as a new version. The previous content is saved
+// first if the file has no versions yet, so the file can be restored to it.
+func (tx *Transaction) saveVersions(c *change) error {
+	versions, err := tx.History.List(c.File)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 && c.Backup != "" {
+		previous, err := os.ReadFile(c.Backup)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.History.Save(c.File, previous); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.History.Save(c.File, c.content)
+	return err
+}
+
+// Restores the changed files to their previous content. Does nothing if the transaction is committed,
+// so it can be deferred right after the transaction begins.
+func (tx *Transaction) Rollback() {
+	if tx.done {
+		return
+	}
+	tx.done = true
+
+	rollback(tx.changes)
+
+	if err := os.Remove(tx.journal); err != nil {
+		log.Printf("Error removing transaction journal %s: %s\n", tx.journal, err)
+	}
+}
+
+func rollback(changes []*change) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		log.Println("> Rollback " + c.File)
+
+		var err error
+		if c.Backup != "" {
+			err = os.Rename(c.Backup, c.File)
+		} else {
+			err = os.Remove(c.File)
+		}
+
+		// A missing file means it was restored already by an interrupted recovery
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Error restoring %s: %s\n", c.File, err)
+			continue
+		}
+
+		if err := syncDir(filepath.Dir(c.File)); err != nil {
+			log.Printf("Error restoring %s: %s\n", c.File, err)
+		}
+	}
+}
+
+// Rolls back the transactions with journals in `dir`, left by a crash before they ended.
+// Returns the restored files.
+func Recover(dir string) ([]string, error) {
+	journals, err := filepath.Glob(filepath.Join(dir, journalPattern))
+	if err != nil {
+		return nil, err
+	}
+
+	restored := []string{}
+
+	for _, journal := range journals {
+		content, err := os.ReadFile(journal)
+		if err != nil {
+			return restored, err
+		}
+
+		changes := []*change{}
+		if strings.TrimSpace(string(content)) != "" {
+			if err := json.Unmarshal(content, &changes); err != nil {
+				return restored, err
+			}
+		}
+
+		rollback(changes)
+
+		for _, c := range changes {
+			restored = append(restored, c.File)
+		}
+
+		if err := os.Remove(journal); err != nil {
+			return restored, err
+		}
+	}
+
+	return restored, nil
+}
@@ -0,0 +1,155 @@
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+func TestTransaction(t *testing.T) {
+	dir := t.TempDir()
+	txDir := filepath.Join(dir, ".transactions")
+	conf := filepath.Join(dir, "named.conf.local")
+	zone := filepath.Join(dir, "db.example.com")
+
+	if err := os.WriteFile(conf, []byte("old conf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rolled back changes restore the previous files and remove the created ones
+	tx, err := file.Begin(txDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, tx.Write(zone, []byte("new zone")))
+	assert.Nil(t, tx.Write(conf, []byte("new conf")))
+	assert.Nil(t, tx.Write(conf, []byte("newer conf")))
+
+	content, _ := os.ReadFile(conf)
+	assert.Equal(t, "newer conf", string(content))
+
+	tx.Rollback()
+
+	content, _ = os.ReadFile(conf)
+	assert.Equal(t, "old conf", string(content))
+	assert.NoFileExists(t, zone)
+
+	// Committed changes are kept
+	tx, err = file.Begin(txDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, tx.Write(zone, []byte("new zone")))
+	tx.Commit()
+	tx.Rollback()
+
+	content, _ = os.ReadFile(zone)
+	assert.Equal(t, "new zone", string(content))
+
+	journals, _ := filepath.Glob(filepath.Join(txDir, "*"))
+	assert.Empty(t, journals)
+}
+
+func TestRecover(t *testing.T) {
+	dir := t.TempDir()
+	txDir := filepath.Join(dir, ".transactions")
+	conf := filepath.Join(dir, "named.conf.local")
+	zone := filepath.Join(dir, "db.example.com")
+
+	if err := os.WriteFile(zone, []byte("old zone"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// A transaction that never ends, like after a crash
+	tx, err := file.Begin(txDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, tx.Remove(zone))
+	assert.Nil(t, tx.Write(conf, []byte("new conf")))
+
+	restored, err := file.Recover(txDir)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{zone, conf}, restored)
+
+	content, _ := os.ReadFile(zone)
+	assert.Equal(t, "old zone", string(content))
+	assert.NoFileExists(t, conf)
+
+	info, err := os.Stat(zone)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	restored, err = file.Recover(txDir)
+	assert.Nil(t, err)
+	assert.Empty(t, restored)
+}
+
+func TestTransactionConflict(t *testing.T) {
+	dir := t.TempDir()
+	zone := filepath.Join(dir, "db.example.com")
+
+	if err := os.WriteFile(zone, []byte("loaded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := file.NewTracker()
+	assert.Nil(t, tracker.Track(zone))
+	assert.Empty(t, tracker.Changed())
+
+	// Written files are tracked again on commit
+	tx, err := file.Begin(filepath.Join(dir, ".transactions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Tracker = tracker
+
+	assert.Nil(t, tx.Write(zone, []byte("written")))
+	tx.Commit()
+	assert.Empty(t, tracker.Changed())
+
+	// Edits of others are reported once, and writes conflict until the file is tracked again
+	if err := os.WriteFile(zone, []byte("edited by hand"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{zone}, tracker.Changed())
+	assert.Empty(t, tracker.Changed())
+
+	tx, err = file.Begin(filepath.Join(dir, ".transactions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Tracker = tracker
+
+	var conflictErr *file.ConflictError
+	assert.ErrorAs(t, tx.Write(zone, []byte("overwritten")), &conflictErr)
+	tx.Rollback()
+
+	content, _ := os.ReadFile(zone)
+	assert.Equal(t, "edited by hand", string(content))
+
+	// Files created by others are not overwritten either
+	if err := os.WriteFile(zone+".new", []byte("created by hand"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorAs(t, tracker.Check(zone+".new"), &conflictErr)
+	assert.Nil(t, tracker.Track(zone))
+	assert.Nil(t, tracker.Check(zone))
+	assert.Nil(t, tracker.Check(filepath.Join(dir, "db.example.org")))
+
+	// Files shared with others are neither reported nor checked
+	tracker.Shared = func(filename string) bool { return filename == zone }
+
+	if err := os.WriteFile(zone, []byte("written by others"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, tracker.Changed())
+	assert.Nil(t, tracker.Check(zone))
+}
@@ -0,0 +1,279 @@
+package gitstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Files of the API and of BIND that are never committed
+const gitignore = `.transactions/
+.history/
+*.bak
+*.check
+.*.tmp-*
+*.jnl
+`
+
+// Git repository that records the changes to the BIND files, one commit per change.
+// The repository metadata is kept apart from the work tree, which holds the BIND directories.
+type Store struct {
+	GitDir   string
+	WorkTree string
+}
+
+// Change recorded by a commit.
+type Change struct {
+	Actor    string `json:"actor"`
+	Endpoint string `json:"endpoint"`
+	Message  string `json:"message"`
+}
+
+// Commit of the store, with the change it records.
+type Commit struct {
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+	Change
+}
+
+// Error of a git command, with its error output.
+type GitError struct {
+	Args   []string
+	Stderr string
+}
+
+func (e *GitError) Error() string {
+	return fmt.Sprintf("git %s failed: %s", strings.Join(e.Args, " "), strings.TrimSpace(e.Stderr))
+}
+
+// Opens the store, creating the repository if it does not exist.
+func Open(gitDir, workTree string) (*Store, error) {
+	gitDir, err := filepath.Abs(gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	workTree, err = filepath.Abs(workTree)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{GitDir: gitDir, WorkTree: workTree}
+
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); os.IsNotExist(err) {
+		if _, err := s.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+
+		// The work tree is not a repository itself, so the ignored files are set in the metadata
+		if err := os.MkdirAll(filepath.Join(gitDir, "info"), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(gitDir, "info", "exclude"), []byte(gitignore), 0644); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"--git-dir", s.GitDir, "--work-tree", s.WorkTree}, args...)...)
+	cmd.Dir = s.WorkTree
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return stdout.String(), &GitError{Args: args, Stderr: stderr.String()}
+		}
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+// Returns the path of a file relative to the work tree, as git expects it.
+func (s *Store) relative(filename string) (string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(s.WorkTree, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("file %s is outside of the git work tree %s", filename, s.WorkTree)
+	}
+
+	return rel, nil
+}
+
+// Returns true if the repository has no commits yet.
+func (s *Store) Empty() bool {
+	_, err := s.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return err != nil
+}
+
+// Returns the paths of the tracked files with uncommitted changes.
+// Files unknown to the repository are not considered.
+func (s *Store) Drift() ([]string, error) {
+	output, err := s.git("status", "--porcelain", "--untracked-files=no", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		files = append(files, filepath.Join(s.WorkTree, entry[3:]))
+
+		// Renames are followed by their source path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+
+	return files, nil
+}
+
+// Commits the changes of the files, added, modified or removed. Returns the hash of the commit,
+// or an empty hash if the files have no changes.
+func (s *Store) Commit(files []string, change Change) (string, error) {
+	added, removed := []string{}, []string{}
+	for _, filename := range files {
+		rel, err := s.relative(filename)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			removed = append(removed, rel)
+		} else {
+			added = append(added, rel)
+		}
+	}
+
+	if len(added) > 0 {
+		if _, err := s.git(append([]string{"add", "--all", "--"}, added...)...); err != nil {
+			return "", err
+		}
+	}
+
+	// Files that were never committed are ignored
+	if len(removed) > 0 {
+		if _, err := s.git(append([]string{"rm", "--cached", "--quiet", "--ignore-unmatch", "--"}, removed...)...); err != nil {
+			return "", err
+		}
+	}
+
+	// Nothing staged, the files were written with the same content
+	if _, err := s.git("diff", "--cached", "--quiet"); err == nil {
+		return "", nil
+	}
+
+	message := fmt.Sprintf("%s\n\nActor: %s\nEndpoint: %s\n", change.Message, change.Actor, change.Endpoint)
+
+	// Git refuses commits without an author name
+	author := change.Actor
+	if author == "" {
+		author = "unknown"
+	}
+
+	_, err := s.git(
+		"-c", "user.name=bind-api", "-c", "user.email=bind-api@localhost",
+		"commit", "--quiet", "--author", author+" <>", "--message", message,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := s.git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(hash), nil
+}
+
+// Returns the newest commits, up to limit, that change the file or any file if it is empty.
+func (s *Store) Log(filename string, limit int) ([]*Commit, error) {
+	if s.Empty() {
+		return []*Commit{}, nil
+	}
+
+	// Fields are separated by the unit separator and commits by the record separator
+	args := []string{"log", "--format=%H%x1f%aI%x1f%B%x1e", "-n", strconv.Itoa(limit)}
+
+	if filename != "" {
+		rel, err := s.relative(filename)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--", rel)
+	}
+
+	output, err := s.git(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []*Commit{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		commitTime, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		commits = append(commits, &Commit{Hash: fields[0], Time: commitTime, Change: parseMessage(fields[2])})
+	}
+
+	return commits, nil
+}
+
+// Reads the change from a commit message, the actor and endpoint are trailers after the message.
+func parseMessage(message string) Change {
+	change := Change{}
+	lines := []string{}
+
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		if actor, ok := cutPrefix(line, "Actor: "); ok {
+			change.Actor = actor
+		} else if endpoint, ok := cutPrefix(line, "Endpoint: "); ok {
+			change.Endpoint = endpoint
+		} else {
+			lines = append(lines, line)
+		}
+	}
+
+	change.Message = strings.TrimSpace(strings.Join(lines, "\n"))
+
+	return change
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return strings.TrimPrefix(s, prefix), true
+}
@@ -0,0 +1,79 @@
+package gitstore_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/pkg/gitstore"
+)
+
+func TestStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	workTree := filepath.Join(dir, "bind")
+	conf := filepath.Join(workTree, "conf", "named.conf.local")
+	zone := filepath.Join(workTree, "lib", "db.example.com")
+
+	for _, filename := range []string{conf, zone} {
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := gitstore.Open(filepath.Join(dir, "git"), workTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, store.Empty())
+
+	hash, err := store.Commit([]string{conf, zone}, gitstore.Change{Actor: "admin", Endpoint: "startup", Message: "Initial configuration"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, hash)
+
+	// Unchanged files make no commit
+	hash, err = store.Commit([]string{zone}, gitstore.Change{Actor: "admin", Endpoint: "POST /api/zones", Message: "nothing"})
+	assert.Nil(t, err)
+	assert.Empty(t, hash)
+
+	// Edits out of the API are drift, ignored files and untracked ones are not
+	assert.Nil(t, os.WriteFile(zone, []byte("v2"), 0644))
+	assert.Nil(t, os.WriteFile(zone+".jnl", []byte("journal"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(workTree, "lib", "db.example.org"), []byte("v1"), 0644))
+
+	drift, err := store.Drift()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{zone}, drift)
+
+	assert.Nil(t, os.Remove(zone))
+	neverCommitted := filepath.Join(workTree, "lib", "db.example.net")
+
+	hash, err = store.Commit([]string{zone, neverCommitted}, gitstore.Change{Actor: "alice", Endpoint: "DELETE /api/zones/example.com", Message: "Remove example.com"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, hash)
+
+	drift, err = store.Drift()
+	assert.Nil(t, err)
+	assert.Empty(t, drift)
+
+	commits, err := store.Log(zone, 10)
+	assert.Nil(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, hash, commits[0].Hash)
+	assert.Equal(t, gitstore.Change{Actor: "alice", Endpoint: "DELETE /api/zones/example.com", Message: "Remove example.com"}, commits[0].Change)
+
+	commits, err = store.Log("", 1)
+	assert.Nil(t, err)
+	assert.Len(t, commits, 1)
+
+	_, err = store.Commit([]string{filepath.Join(dir, "outside")}, gitstore.Change{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,151 @@
+package rndc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Types of the values of the control channel messages, see lib/isccc in the BIND sources.
+const (
+	typeBinary = 1
+	typeTable  = 2
+	typeList   = 3
+)
+
+var errTruncated = errors.New("truncated message")
+
+// Key value pairs of a message, kept in order since the signature covers their serialization.
+type table []element
+
+type element struct {
+	key string
+	// string, []byte or table
+	value any
+	// serialization of the element as received, used to verify signatures
+	raw []byte
+}
+
+func (t table) get(key string) any {
+	for _, e := range t {
+		if e.key == key {
+			return e.value
+		}
+	}
+	return nil
+}
+
+func (t table) table(key string) table {
+	if value, ok := t.get(key).(table); ok {
+		return value
+	}
+	return nil
+}
+
+func (t table) string(key string) string {
+	switch value := t.get(key).(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	}
+	return ""
+}
+
+// Replaces the value of the key, or appends it if the table does not have it.
+func (t *table) set(key string, value any) {
+	for i, e := range *t {
+		if e.key == key {
+			(*t)[i] = element{key: key, value: value}
+			return
+		}
+	}
+	*t = append(*t, element{key: key, value: value})
+}
+
+// Serializes the elements of the table, skipping the ones named in `skip`.
+func (t table) marshal(skip string) []byte {
+	data := []byte{}
+	for _, e := range t {
+		if e.key != skip {
+			data = append(data, e.marshal()...)
+		}
+	}
+	return data
+}
+
+func (e element) marshal() []byte {
+	var typ byte
+	var value []byte
+
+	switch v := e.value.(type) {
+	case string:
+		typ, value = typeBinary, []byte(v)
+	case []byte:
+		typ, value = typeBinary, v
+	case table:
+		typ, value = typeTable, v.marshal("")
+	}
+
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(value)))
+
+	data := append([]byte{byte(len(e.key))}, e.key...)
+	data = append(data, typ)
+	data = append(data, length...)
+
+	return append(data, value...)
+}
+
+func unmarshalTable(data []byte) (table, error) {
+	t := table{}
+
+	for len(data) > 0 {
+		e, rest, err := unmarshalElement(data)
+		if err != nil {
+			return nil, err
+		}
+		t = append(t, e)
+		data = rest
+	}
+
+	return t, nil
+}
+
+func unmarshalElement(data []byte) (element, []byte, error) {
+	if len(data) < 1 || len(data) < 1+int(data[0])+5 {
+		return element{}, nil, errTruncated
+	}
+
+	keyLen := int(data[0])
+	e := element{key: string(data[1 : 1+keyLen])}
+
+	typ := data[1+keyLen]
+	valueLen := int(binary.BigEndian.Uint32(data[2+keyLen:]))
+
+	start := 6 + keyLen
+	if len(data) < start+valueLen {
+		return element{}, nil, errTruncated
+	}
+
+	value := data[start : start+valueLen]
+	e.raw = data[:start+valueLen]
+
+	switch typ {
+	case typeBinary:
+		e.value = value
+	case typeTable:
+		t, err := unmarshalTable(value)
+		if err != nil {
+			return element{}, nil, err
+		}
+		e.value = t
+	case typeList:
+		// Not used by the commands of the API
+		e.value = value
+	default:
+		return element{}, nil, fmt.Errorf("unknown value type %d", typ)
+	}
+
+	return e, data[start+valueLen:], nil
+}
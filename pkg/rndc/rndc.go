@@ -0,0 +1,254 @@
+// Client of the rndc control channel protocol, used to send commands to BIND
+// through the `controls` statement without running the rndc tool.
+package rndc
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type algorithm struct {
+	// Identifier of the algorithm in `hsha` signatures
+	id   byte
+	hash func() hash.Hash
+}
+
+var algorithms = map[string]algorithm{
+	"hmac-md5":    {157, md5.New},
+	"hmac-sha1":   {161, sha1.New},
+	"hmac-sha224": {162, sha256.New224},
+	"hmac-sha256": {163, sha256.New},
+	"hmac-sha384": {164, sha512.New384},
+	"hmac-sha512": {165, sha512.New},
+}
+
+const (
+	version = 1
+	// Length of the base64 signature of `hmd5` and `hsha` values
+	md5Length = 22
+	shaLength = 88
+	// Messages larger than this are rejected
+	maxMessage = 32 * 1024 * 1024
+)
+
+var ErrAuth = errors.New("rndc: response signature is not valid")
+
+// Error returned when BIND fails to run a command, with the message reported by the server.
+type Error struct {
+	Command string
+	// ISC result code reported by the server, or the exit status of the rndc tool
+	Result  string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rndc %s: %s", e.Command, e.Message)
+}
+
+type Client struct {
+	// Address of the control channel, like 127.0.0.1:953
+	Address string
+	Timeout time.Duration
+
+	algorithm algorithm
+	secret    []byte
+
+	mutex  sync.Mutex
+	serial uint32
+}
+
+// Creates a client authenticated with the key shared with BIND, the secret is base64 encoded
+// as it appears in the `key` statement.
+func NewClient(address, algorithmName, secret string) (*Client, error) {
+	alg, ok := algorithms[strings.ToLower(algorithmName)]
+	if !ok {
+		return nil, fmt.Errorf("rndc: unsupported algorithm %s", algorithmName)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("rndc: invalid secret: %w", err)
+	}
+
+	return &Client{
+		Address:   address,
+		Timeout:   10 * time.Second,
+		algorithm: alg,
+		secret:    decoded,
+		serial:    uint32(time.Now().UnixNano()),
+	}, nil
+}
+
+// Runs a command, like `reload example.com`, and returns the text reported by BIND.
+func (c *Client) Call(command string) (string, error) {
+	conn, err := net.DialTimeout("tcp", c.Address, c.Timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return "", err
+	}
+
+	// The server requires a nonce, obtained with a `null` command, in every other command
+	response, err := c.roundTrip(conn, "null", "")
+	if err != nil {
+		return "", err
+	}
+
+	nonce := response.table("_ctrl").string("_nonce")
+	if nonce == "" {
+		return "", errors.New("rndc: server did not send a nonce")
+	}
+
+	response, err = c.roundTrip(conn, command, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	data := response.table("_data")
+	text := data.string("text")
+
+	if result := data.string("result"); result != "" && result != "0" {
+		message := data.string("err")
+		if message == "" {
+			message = "failed with result " + result
+		}
+		return text, &Error{Command: command, Result: result, Message: message}
+	}
+
+	return text, nil
+}
+
+func (c *Client) roundTrip(conn io.ReadWriter, command, nonce string) (table, error) {
+	if _, err := conn.Write(c.request(command, nonce)); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		// BIND closes the connection without answering when the key is wrong
+		return nil, fmt.Errorf("rndc: reading response: %w", err)
+	}
+
+	length := binary.BigEndian.Uint32(header)
+	if v := binary.BigEndian.Uint32(header[4:]); v != version {
+		return nil, fmt.Errorf("rndc: unsupported message version %d", v)
+	}
+	if length < 4 || length > maxMessage {
+		return nil, fmt.Errorf("rndc: invalid message length %d", length)
+	}
+
+	body := make([]byte, length-4)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, fmt.Errorf("rndc: reading response: %w", err)
+	}
+
+	response, err := unmarshalTable(body)
+	if err != nil {
+		return nil, fmt.Errorf("rndc: %w", err)
+	}
+
+	if !c.verify(response) {
+		return nil, ErrAuth
+	}
+
+	if nonce != "" && response.table("_ctrl").string("_nonce") != nonce {
+		return nil, errors.New("rndc: response nonce does not match")
+	}
+
+	return response, nil
+}
+
+// Builds a signed request, prefixed with its length and version.
+func (c *Client) request(command, nonce string) []byte {
+	c.mutex.Lock()
+	c.serial++
+	serial := c.serial
+	c.mutex.Unlock()
+
+	now := time.Now().Unix()
+
+	ctrl := table{}
+	ctrl.set("_ser", strconv.FormatUint(uint64(serial), 10))
+	ctrl.set("_tim", strconv.FormatInt(now, 10))
+	ctrl.set("_exp", strconv.FormatInt(now+60, 10))
+	if nonce != "" {
+		ctrl.set("_nonce", nonce)
+	}
+
+	message := table{
+		{key: "_auth", value: table{}},
+		{key: "_ctrl", value: ctrl},
+		{key: "_data", value: table{{key: "type", value: command}}},
+	}
+
+	message.set("_auth", c.sign(message.marshal("_auth")))
+
+	body := message.marshal("")
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(body)+4))
+	binary.BigEndian.PutUint32(header[4:], version)
+
+	return append(header, body...)
+}
+
+// Returns the `_auth` table with the signature of the serialized message.
+func (c *Client) sign(data []byte) table {
+	if c.algorithm.id == algorithms["hmac-md5"].id {
+		return table{{key: "hmd5", value: []byte(c.digest(data)[:md5Length])}}
+	}
+
+	hsha := make([]byte, 1+shaLength)
+	hsha[0] = c.algorithm.id
+	copy(hsha[1:], c.digest(data))
+
+	return table{{key: "hsha", value: hsha}}
+}
+
+func (c *Client) digest(data []byte) string {
+	mac := hmac.New(c.algorithm.hash, c.secret)
+	mac.Write(data)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Checks the signature of a response, which covers the raw elements that follow `_auth`.
+func (c *Client) verify(response table) bool {
+	signed := []byte{}
+	for _, e := range response {
+		if e.key != "_auth" {
+			signed = append(signed, e.raw...)
+		}
+	}
+
+	auth := response.table("_auth")
+	if auth == nil {
+		return false
+	}
+
+	expected := c.sign(signed)
+	key := expected[0].key
+
+	received, ok := auth.get(key).([]byte)
+	if !ok {
+		return false
+	}
+
+	return hmac.Equal(received, expected[0].value.([]byte))
+}
@@ -0,0 +1,187 @@
+package rndc_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/pkg/rndc"
+)
+
+const secret = "c2VjcmV0IHNoYXJlZCB3aXRoIHRoZSBzdHViIHNlcnZlcg=="
+
+type field struct {
+	key   string
+	value any
+}
+
+func encode(fields []field) []byte {
+	var buf bytes.Buffer
+	for _, f := range fields {
+		var typ byte = 1
+		var value []byte
+
+		switch v := f.value.(type) {
+		case string:
+			value = []byte(v)
+		case []byte:
+			value = v
+		case []field:
+			typ, value = 2, encode(v)
+		}
+
+		buf.WriteByte(byte(len(f.key)))
+		buf.WriteString(f.key)
+		buf.WriteByte(typ)
+		binary.Write(&buf, binary.BigEndian, uint32(len(value)))
+		buf.Write(value)
+	}
+	return buf.Bytes()
+}
+
+// Decodes the top level elements of a message, returning their values and the bytes covered by the signature.
+func decode(data []byte) (map[string]any, []byte) {
+	values := map[string]any{}
+	signed := []byte{}
+
+	for len(data) > 0 {
+		keyLen := int(data[0])
+		key := string(data[1 : 1+keyLen])
+		typ := data[1+keyLen]
+		valueLen := int(binary.BigEndian.Uint32(data[2+keyLen:]))
+		end := 6 + keyLen + valueLen
+		value := data[6+keyLen : end]
+
+		if typ == 2 {
+			values[key], _ = decode(value)
+		} else {
+			values[key] = string(value)
+		}
+
+		if key != "_auth" {
+			signed = append(signed, data[:end]...)
+		}
+		data = data[end:]
+	}
+
+	return values, signed
+}
+
+func hsha(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	value := make([]byte, 89)
+	value[0] = 163
+	copy(value[1:], base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	return value
+}
+
+// Serves the control channel protocol with a sha256 key, answering commands with `handle`.
+func stubServer(t *testing.T, handle func(command string) []field) string {
+	key, _ := base64.StdEncoding.DecodeString(secret)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				nonce := "12345"
+
+				for {
+					header := make([]byte, 8)
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+
+					body := make([]byte, binary.BigEndian.Uint32(header)-4)
+					if _, err := io.ReadFull(conn, body); err != nil {
+						return
+					}
+
+					request, signed := decode(body)
+					auth := request["_auth"].(map[string]any)
+					if !hmac.Equal([]byte(auth["hsha"].(string)), hsha(key, signed)) {
+						return
+					}
+
+					command := request["_data"].(map[string]any)["type"].(string)
+					data := []field{{"type", command}, {"result", "0"}}
+					if command != "null" {
+						data = append([]field{{"type", command}}, handle(command)...)
+					}
+
+					ctrl := []field{{"_rpl", "1"}, {"_nonce", nonce}}
+					signedResponse := encode([]field{{"_ctrl", ctrl}, {"_data", data}})
+					response := append(encode([]field{{"_auth", []field{{"hsha", hsha(key, signedResponse)}}}}), signedResponse...)
+
+					binary.Write(conn, binary.BigEndian, []uint32{uint32(len(response) + 4), 1})
+					conn.Write(response)
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestClient(t *testing.T) {
+	address := stubServer(t, func(command string) []field {
+		switch {
+		case command == "status":
+			return []field{{"result", "0"}, {"text", "server is up and running"}}
+		case strings.HasPrefix(command, "reload "):
+			return []field{{"result", "29"}, {"err", "not found"}}
+		}
+		return []field{{"result", "0"}}
+	})
+
+	client, err := rndc.NewClient(address, "hmac-sha256", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := client.Call("status")
+	assert.Nil(t, err)
+	assert.Equal(t, "server is up and running", text)
+
+	_, err = client.Call("reconfig")
+	assert.Nil(t, err)
+
+	_, err = client.Call("reload missing.example")
+	var rndcErr *rndc.Error
+	if assert.True(t, errors.As(err, &rndcErr)) {
+		assert.Equal(t, "29", rndcErr.Result)
+		assert.Equal(t, "rndc reload missing.example: not found", err.Error())
+	}
+
+	wrongKey, err := rndc.NewClient(address, "hmac-sha256", base64.StdEncoding.EncodeToString([]byte("wrong")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = wrongKey.Call("status")
+	assert.NotNil(t, err)
+
+	_, err = rndc.NewClient(address, "hmac-sha3", secret)
+	assert.NotNil(t, err)
+}
@@ -2,6 +2,8 @@ package setting
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/go-ini/ini"
 )
@@ -18,13 +20,64 @@ type BindSetting struct {
 	LibPath     string
 	Admin       string
 	ContainerId string
+	// Directory, as seen by BIND, where the files of new zones are created
+	ZonesDir string
+	// Paths as seen by BIND mapped to paths as seen by the API, like /var/lib/bind/=data/bind/lib/.
+	// By default /etc/bind/ is mapped to ConfPath and /var/lib/bind/ to LibPath.
+	PathMap []string
+	// Name of the catalog zone listing the zones managed by the API, empty to disable it
+	CatalogZone string
+	// Addresses of the secondaries that transfer the catalog zone
+	CatalogSecondaries []string
+	// Address of the rndc control channel, like 127.0.0.1:953.
+	// If empty rndc commands are run in the container.
+	RndcAddress string
+	// File with the rndc key, by default rndc.key in ConfPath
+	RndcKeyFile string
+	// Where commands like rndc or named-checkzone are run: docker, in the container, or local
+	Runner string
+	// Time limit of the commands, 30s by default
+	CommandTimeout time.Duration
+	// Skip the named-checkzone and named-checkconf checks of the files before they are written
+	SkipChecks bool
+	// Reject zone changes that introduce validation warnings, not only errors
+	StrictValidation bool
+	// Address where BIND receives dynamic updates and zone transfer requests, 127.0.0.1:53 by default
+	DnsAddress string
+	// Versions kept of each file written by the API, 10 by default. A negative value disables the history.
+	HistorySize int
+	// Directory of a git repository, created if it does not exist, where each change is committed.
+	// Its work tree is the closest directory holding ConfPath and LibPath. Requires the git binary.
+	GitDir string
+	// Interval between checks of the BIND files for changes made out of the API, which are loaded
+	// and refused to be overwritten until then. 2s by default, a negative value disables the checks.
+	WatchInterval time.Duration
+	// Window in which the record changes of a zone are batched in a single write and reload.
+	// Zero disables the batching, so each change is written and reloaded on its own.
+	WriteBehind time.Duration
+	// Add and delete zones with rndc addzone and delzone instead of rewriting named.conf.local and
+	// reconfiguring BIND. Requires allow-new-zones in the options, and the working directory of BIND,
+	// where it keeps the added zones in an NZF or NZD file, mapped in PathMap.
+	AllowNewZones bool
 }
 
 var Bind = &BindSetting{}
 
+// BIND server of a fleet, set in a [bind.<name>] section. Settings left out are taken from [bind],
+// but each server needs its own paths.
+type ServerSetting struct {
+	Name string
+	Bind *BindSetting
+}
+
+// Servers managed together, changes to a zone are applied in every server holding it.
+// Empty to manage the single server of the [bind] section.
+var Servers = []*ServerSetting{}
+
 var cfg *ini.File
 
-func init() {
+// Loads the settings from data/api/app.ini, it must be called before using them.
+func Setup() {
 	var err error
 	cfg, err = ini.Load("data/api/app.ini")
 	if err != nil {
@@ -33,6 +86,13 @@ func init() {
 
 	mapTo("app", App)
 	mapTo("bind", Bind)
+
+	Servers = []*ServerSetting{}
+	for _, section := range cfg.Section("bind").ChildSections() {
+		server := &ServerSetting{Name: strings.TrimPrefix(section.Name(), "bind."), Bind: &BindSetting{}}
+		mapTo(section.Name(), server.Bind)
+		Servers = append(Servers, server)
+	}
 }
 
 func mapTo(section string, v interface{}) {
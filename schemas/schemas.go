@@ -1,5 +1,7 @@
 package schemas
 
+import "github.com/svex99/bind-api/pkg/dnssec"
+
 type ZoneData struct {
 	Origin     string `json:"origin"`
 	Ttl        string `json:"ttl"`
@@ -9,4 +11,145 @@ type ZoneData struct {
 	Retry      uint   `json:"retry" binding:"gt=0"`
 	Expire     uint   `json:"expire" binding:"gt=0"`
 	Minimum    uint   `json:"minimum" binding:"gt=0"`
+	// Catalog zone groups of the zone, not modified on updates if left out
+	Groups []string `json:"groups"`
+	// Servers of the fleet where the zone is created, all of them if left out. Ignored on updates.
+	Servers []string `json:"servers"`
+}
+
+type KeyData struct {
+	Name      string `json:"name" binding:"required"`
+	Algorithm string `json:"algorithm" binding:"required,oneof=hmac-sha256 hmac-sha512"`
+}
+
+type KeyInfo struct {
+	Name       string   `json:"name"`
+	Algorithm  string   `json:"algorithm"`
+	References []string `json:"references"`
+}
+
+// Key returned only when it is created or rotated, the secret is never listed.
+type KeySecret struct {
+	Name      string `json:"name"`
+	Algorithm string `json:"algorithm"`
+	Secret    string `json:"secret"`
+}
+
+// Changes to the global options, fields left out of the request are not modified.
+// Empty strings and lists remove the option so BIND falls back to its default.
+type OptionsData struct {
+	Directory        *string  `json:"directory"`
+	Recursion        *bool    `json:"recursion"`
+	Forward          *string  `json:"forward"`
+	Forwarders       []string `json:"forwarders"`
+	AllowQuery       []string `json:"allowQuery"`
+	AllowRecursion   []string `json:"allowRecursion"`
+	AllowTransfer    []string `json:"allowTransfer"`
+	DnssecValidation *string  `json:"dnssecValidation"`
+	KeyDirectory     *string  `json:"keyDirectory"`
+}
+
+type DnssecData struct {
+	// Name of the dnssec-policy, empty to remove the policy from the zone
+	Policy        string `json:"policy"`
+	InlineSigning bool   `json:"inlineSigning"`
+}
+
+type DnssecStatus struct {
+	Policy        string `json:"policy"`
+	InlineSigning bool   `json:"inlineSigning"`
+	// Output of `rndc dnssec -status`, empty if the zone has no policy
+	Status string `json:"status"`
+}
+
+type DnssecKey struct {
+	KeyTag    uint16 `json:"keyTag"`
+	Algorithm uint8  `json:"algorithm"`
+	// KSK, ZSK or CSK (combined signing key)
+	Role string `json:"role"`
+	// Content of the key state file, empty for keys not managed by a dnssec-policy
+	State map[string]string `json:"state"`
+	// DS records of the key, only for KSKs and CSKs
+	DS []*dnssec.DS `json:"ds"`
+	// Action needed in the parent zone to continue a rollover, `publish` or `withdraw`
+	ParentAction string `json:"parentAction"`
+}
+
+type ZoneKeys struct {
+	Keys []*DnssecKey `json:"keys"`
+	// Keys waiting for their DS to be published or withdrawn in the parent zone
+	Pending []*DnssecKey `json:"pending"`
+}
+
+type RPZData struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type RPZRuleData struct {
+	Domain string `json:"domain" binding:"required"`
+	Action string `json:"action" binding:"required,oneof=block nxdomain passthru redirect"`
+	// Domain the answers are redirected to, required by redirect rules
+	Target string `json:"target"`
+	// Also apply the rule to every subdomain
+	Subdomains bool `json:"subdomains"`
+}
+
+// Options of a blocklist import, read from the query string.
+type BlocklistData struct {
+	// Action applied to every listed domain, nxdomain by default
+	Action     string `form:"action" binding:"omitempty,oneof=block nxdomain"`
+	Subdomains bool   `form:"subdomains"`
+}
+
+type BlocklistResult struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Total   int `json:"total"`
+	// Entries of the list that are not valid domains
+	Skipped int `json:"skipped"`
+}
+
+type DynamicUpdateData struct {
+	// Key that signs the dynamic updates of the zone, empty to edit the zone file instead
+	Key string `json:"key"`
+}
+
+// Record change queued in write-behind mode, polled by its id until it is applied or failed.
+type QueuedChange struct {
+	Id     string `json:"id"`
+	Origin string `json:"origin"`
+	// pending, applied or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Result of a change in one server of the fleet.
+type ServerResult struct {
+	Server string `json:"server"`
+	// Empty if the change was applied in the server
+	Error string `json:"error,omitempty"`
+}
+
+type ServerInfo struct {
+	Name string `json:"name"`
+	// Origins of the zones held by the server
+	Zones []string `json:"zones"`
+}
+
+type ZoneServersData struct {
+	Servers []string `json:"servers" binding:"required,min=1"`
+}
+
+// Content of a zone in one server, compared between the servers holding the zone.
+type ZoneState struct {
+	Server string `json:"server"`
+	Serial uint   `json:"serial"`
+	// SHA-256 of the zone content, the serial left out
+	Digest string `json:"digest"`
+}
+
+// Zone whose content differs between the servers holding it.
+type ZoneDrift struct {
+	Origin  string       `json:"origin"`
+	Servers []*ZoneState `json:"servers"`
 }
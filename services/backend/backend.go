@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// DNS server managed by the API. Implementations must be safe for concurrent use.
+type Backend interface {
+	ListZones() []*parser.ZoneConf
+	GetZone(origin string) (*parser.ZoneConf, error)
+	CreateZone(data *schemas.ZoneData) (*parser.ZoneConf, error)
+	UpdateZone(origin string, data *schemas.ZoneData) (*parser.ZoneConf, error)
+	DeleteZone(origin string) error
+
+	AddRecord(origin string, record parser.Record) error
+	UpdateRecord(origin, target string, record parser.Record) error
+	DeleteRecord(origin string, record parser.Record) error
+
+	// Reloads a zone in the server, or the whole configuration if origin is empty
+	Reload(origin string) error
+}
+
+// Backend that can batch record changes, applying the ones of a zone in a single write and reload.
+// Queued changes return as soon as they are validated, the returned change is nil if it was applied already.
+type Batcher interface {
+	QueueAddRecord(origin string, record parser.Record) (*schemas.QueuedChange, error)
+	QueueUpdateRecord(origin, target string, record parser.Record) (*schemas.QueuedChange, error)
+	QueueDeleteRecord(origin string, record parser.Record) (*schemas.QueuedChange, error)
+
+	GetQueuedChange(id string) (*schemas.QueuedChange, error)
+}
+
+// Backend that can create a zone along with its records, used to copy a zone from another server
+// in a single write instead of a change per record.
+type Importer interface {
+	ImportZone(zConf *parser.ZoneConf) (*parser.ZoneConf, error)
+}
+
+// Backend that commits the files changed by each request to a git store, see middlewares.RecordChanges.
+type Recorder interface {
+	Backend
+
+	// Returns a view of the backend, used by a single request, that keeps the files changed through it
+	WithChanges() Recorder
+	// Commits the files changed through the view
+	RecordChange(change gitstore.Change) error
+}
@@ -1,73 +1,284 @@
 package bind
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
+	"github.com/svex99/bind-api/pkg/file"
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/pkg/rndc"
 	"github.com/svex99/bind-api/pkg/setting"
 	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/backend"
 	"github.com/svex99/bind-api/services/bind/parser"
 )
 
 type BindService struct {
-	ctx           context.Context
-	Mutex         *sync.Mutex
-	DockerCli     *client.Client
-	ContainerId   string
-	ZonesFilePath string
-	BindConf      *parser.BindConf
-	Zones         map[string]*parser.ZoneConf
+	*service
+	// Files changed through the service, nil unless it is a view used by a request, see WithChanges
+	changes *changeSet
 }
 
-var Service = &BindService{}
+// State of a BIND server, shared by the service and its views.
+type service struct {
+	// Settings of the server, its paths and how commands are run in it
+	Setting *setting.BindSetting
+	// Lock of the configuration, zone changes also hold the lock of their zone, see locks.go
+	Mutex           *sync.RWMutex
+	Runner          Runner
+	ZonesFilePath   string
+	OptionsFilePath string
+	// Directory of the journals of unfinished file transactions
+	TransactionsDir string
+	PathMap         file.PathMap
+	BindConf        *parser.BindConf
+	OptionsConf     *parser.StatementsFile
+	// Snapshots of the zones, replaced instead of changed
+	Zones   map[string]*parser.ZoneConf
+	Catalog *parser.CatalogZone
+	RPZones map[string]*parser.RPZZone
+	// Control channel client, nil to run rndc in the container
+	Rndc *rndc.Client
+	// Versions of the files written by the API, nil if disabled
+	History *file.History
+	// Repository where each change is committed, nil if disabled
+	Git *gitstore.Store
+	// Files changed out of the views of the service since the last commit to the git store
+	changed []string
+	// Record changes waiting to be written, nil unless in write-behind mode
+	queue *changeQueue
+	// Contents of the files as loaded, writes are refused if they were changed out of the API
+	Tracker *file.Tracker
+	// Files of the dynamic zones, not tracked as BIND writes them too
+	dynamicFiles *dynamicFiles
+	// Guards the snapshots of the zones, the zone locks, the changed and the dynamic files
+	state     *sync.Mutex
+	zoneLocks map[string]*sync.Mutex
+}
+
+var Service = New(setting.Bind)
+
+// Creates the service of a BIND server with its own settings, it must be initialized with Init before using it.
+func New(bindSetting *setting.BindSetting) *BindService {
+	return &BindService{service: &service{Setting: bindSetting}}
+}
+
+var _ backend.Backend = (*BindService)(nil)
+var _ backend.Batcher = (*BindService)(nil)
+var _ backend.Importer = (*BindService)(nil)
+var _ backend.Recorder = (*BindService)(nil)
 
 func (bs *BindService) Init() {
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	var err error
+
+	bs.Mutex = &sync.RWMutex{}
+	bs.state = &sync.Mutex{}
+	bs.zoneLocks = map[string]*sync.Mutex{}
 
+	bs.queue = nil
+	if bs.Setting.WriteBehind > 0 {
+		bs.queue = newChangeQueue(bs.Setting.WriteBehind)
+	}
+	bs.Runner, err = newRunner(bs.Setting)
 	if err != nil {
 		panic(err)
 	}
+	bs.ZonesFilePath = bs.Setting.ConfPath + "named.conf.local"
+	bs.OptionsFilePath = bs.Setting.ConfPath + "named.conf.options"
+	bs.TransactionsDir = bs.Setting.ConfPath + ".transactions/"
+
+	bs.History = nil
+	if bs.Setting.HistorySize >= 0 {
+		limit := bs.Setting.HistorySize
+		if limit == 0 {
+			limit = 10
+		}
+		bs.History = &file.History{Dir: bs.Setting.ConfPath + ".history/", Limit: limit}
+	}
 
-	Service.ctx = context.Background()
-	Service.Mutex = &sync.Mutex{}
-	Service.DockerCli = cli
-	Service.ContainerId = setting.Bind.ContainerId
-	Service.ZonesFilePath = setting.Bind.ConfPath + "named.conf.local"
+	pathMap := bs.Setting.PathMap
+	if len(pathMap) == 0 {
+		pathMap = []string{"/etc/bind/=" + bs.Setting.ConfPath, "/var/lib/bind/=" + bs.Setting.LibPath}
+	}
 
-	Service.Load()
-}
+	bs.PathMap, err = file.ParsePathMap(pathMap)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-func (bs *BindService) Load() {
-	var err error
+	bs.Rndc = nil
+	if bs.Setting.RndcAddress != "" {
+		bs.Rndc, err = newRndcClient(bs.Setting)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	bs.BindConf, err = bs.parseBindConf(Service.ZonesFilePath)
+	// Restore the files of changes interrupted by a crash before they are loaded
+	restored, err := file.Recover(bs.TransactionsDir)
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf(">>> Loaded %d zone(s) from %s\n", len(Service.BindConf.Zones), Service.ZonesFilePath)
 
-	bs.Zones = make(map[string]*parser.ZoneConf)
+	bs.Git, err = openGitStore(bs.Setting)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := bs.Load(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := bs.syncGitStore(); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(restored) > 0 {
+		fmt.Printf(">>> Restored %d file(s) of interrupted changes\n", len(restored))
+
+		// BIND may have loaded the changes before the crash
+		if _, err := bs.control("reload"); err != nil {
+			log.Printf("Error reloading BIND after restoring files: %s\n", err)
+		}
+	}
+
+	if bs.Setting.WatchInterval >= 0 {
+		interval := bs.Setting.WatchInterval
+		if interval == 0 {
+			interval = 2 * time.Second
+		}
+		go bs.watch(interval)
+	}
+}
+
+// Loads the configuration and the files of the primary zones. The loaded state is only replaced
+// when the configuration can be loaded, otherwise the error is returned and the previous state kept.
+func (bs *BindService) Load() error {
+	bindConf, err := bs.parseBindConf(bs.ZonesFilePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(">>> Loaded %d zone(s) from %s\n", len(bindConf.Zones), bs.ZonesFilePath)
+
+	optionsConf, err := bs.parseOptionsConf(bs.OptionsFilePath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(">>> Loaded options from %s\n", bs.OptionsFilePath)
+
+	if err := bs.loadNewZones(bindConf, optionsConf); err != nil {
+		return err
+	}
+
+	previousBindConf, previousOptionsConf, previousCatalog := bs.BindConf, bs.OptionsConf, bs.Catalog
+	previousZones, previousRPZones := bs.zones(), bs.rpZones()
+
+	// The paths of the zone files are resolved against the loaded options
+	bs.BindConf = bindConf
+	bs.OptionsConf = optionsConf
+
+	zones := make(map[string]*parser.ZoneConf)
+	rpZones := make(map[string]*parser.RPZZone)
+
+	rpzNames := map[string]bool{}
+	for _, name := range optionsConf.ResponsePolicyZones() {
+		rpzNames[name] = true
+	}
 
 	fmt.Println(">>> Loading BIND9 zone files")
-	for _, zone := range Service.BindConf.Zones {
-		filename := zone.File[strings.LastIndex(zone.File, "/")+1:]
+	for _, zone := range bindConf.Zones {
+		// Zones of other types, like secondary or forward zones, are left untouched
+		if !zone.IsPrimary() || zone.Name == bs.Setting.CatalogZone {
+			continue
+		}
 
-		zConf, err := Service.parseZoneConf(setting.Bind.LibPath + filename)
+		// Response policy zones hold rules instead of regular records
+		if rpzNames[zone.Name] {
+			rz, err := bs.loadRPZone(zone)
+			if err != nil {
+				log.Printf("Error loading response policy zone %s: %s\n", zone.Name, err)
+				continue
+			}
+			rpZones[rz.Origin] = rz
+			continue
+		}
+
+		if err := bs.syncZone(zone); err != nil {
+			log.Printf("Error syncing journal of zone %s: %s\n", zone.Name, err)
+		}
+
+		zConf, err := bs.loadZoneFile(zone)
 		if err != nil {
-			log.Printf("Error loading %s: %s\n", filename, err)
+			log.Printf("Error loading zone %s: %s\n", zone.Name, err)
 			continue
 		}
 
-		Service.Zones[zConf.Origin] = zConf
-		fmt.Println("Loaded domain file", filename)
+		zones[zConf.Origin] = zConf
+		fmt.Println("Loaded domain file", zone.File)
+	}
+
+	bs.setZones(zones)
+	bs.setRPZones(rpZones)
+
+	bs.Catalog = nil
+	if bs.Setting.CatalogZone != "" {
+		// The catalog is created from the loaded zones if it does not exist, so it is loaded last
+		if err := bs.loadCatalog(); err != nil {
+			bs.BindConf, bs.OptionsConf, bs.Catalog = previousBindConf, previousOptionsConf, previousCatalog
+			bs.setZones(previousZones)
+			bs.setRPZones(previousRPZones)
+			return err
+		}
+	}
+
+	// Changes made out of the API from now on are detected against the loaded files, except in the files
+	// of dynamic zones, which BIND writes whenever it syncs their journal
+	tracker := file.NewTracker()
+	tracker.Shared = bs.isDynamicFile
+	for _, filename := range bs.configFiles(false) {
+		if err := tracker.Track(filename); err != nil {
+			log.Printf("Error tracking file %s: %s\n", filename, err)
+		}
+	}
+	bs.Tracker = tracker
+
+	return nil
+}
+
+// Starts a transaction for the files changed by an operation.
+func (bs *BindService) begin() (*file.Transaction, error) {
+	tx, err := file.Begin(bs.TransactionsDir)
+	if err != nil {
+		return nil, err
+	}
+	tx.History = bs.History
+	tx.Tracker = bs.Tracker
+
+	if bs.Git != nil {
+		tx.OnCommit = bs.addChanged
+	}
+
+	return tx, nil
+}
+
+// Returns the directory, as seen by BIND, where the files of new zones are created.
+func (bs *BindService) zonesDir() string {
+	if bs.Setting.ZonesDir != "" {
+		return bs.Setting.ZonesDir
 	}
+	return "/var/lib/bind/"
+}
+
+// Returns the path used by the API to access a file declared in the BIND configuration.
+func (bs *BindService) resolvePath(bindPath string) (string, error) {
+	return bs.PathMap.Resolve(bindPath, bs.OptionsConf.GetOptions().Directory)
 }
 
 func (bs *BindService) parseBindConf(filename string) (*parser.BindConf, error) {
@@ -84,30 +295,46 @@ func (bs *BindService) parseBindConf(filename string) (*parser.BindConf, error)
 	return bindConf, nil
 }
 
-func (bs *BindService) parseZoneConf(filename string) (*parser.ZoneConf, error) {
-	file, err := os.Open(filename)
+func (bs *BindService) parseZoneConf(filename, origin string) (*parser.ZoneConf, error) {
+	content, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	zConf, err := parser.ZoneParser.Parse(filename, file)
-	if err != nil {
-		return nil, err
+	return parseZone(filename, string(content), origin)
+}
+
+// Parses the content of a zone file. Files in another format than the one written by the API,
+// like the files of dynamic zones written by BIND, are parsed as master files.
+func parseZone(filename, content, origin string) (*parser.ZoneConf, error) {
+	if zConf, err := parser.ZoneParser.ParseString(filename, content); err == nil {
+		return zConf, nil
 	}
 
-	return zConf, nil
+	return parser.ParseMasterFile(filename, strings.NewReader(content), origin)
 }
 
-func (bs *BindService) CreateZone(data *schemas.ZoneData) (*parser.ZoneConf, error) {
-	// Get write access to the filesystem and release it when done
-	bs.Mutex.Lock()
-	defer bs.Mutex.Unlock()
+func (bs *BindService) ListZones() []*parser.ZoneConf {
+	zones := []*parser.ZoneConf{}
+	for _, zConf := range bs.zones() {
+		zones = append(zones, zConf)
+	}
 
-	// Validate that the new zone is not defined already
-	if _, ok := bs.Zones[data.Origin]; ok {
-		return nil, fmt.Errorf("zone %s exists already", data.Origin)
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Origin < zones[j].Origin })
+
+	return zones
+}
+
+func (bs *BindService) GetZone(origin string) (*parser.ZoneConf, error) {
+	zConf, ok := bs.zone(origin)
+	if !ok {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
 	}
 
+	return zConf, nil
+}
+
+func (bs *BindService) CreateZone(data *schemas.ZoneData) (*parser.ZoneConf, error) {
 	// Create the new zone from received data
 	zConf := &parser.ZoneConf{
 		Origin: data.Origin,
@@ -123,71 +350,234 @@ func (bs *BindService) CreateZone(data *schemas.ZoneData) (*parser.ZoneConf, err
 		Records: []parser.Record{},
 	}
 
+	return bs.createZone(zConf, data.Groups)
+}
+
+// Creates a zone with the records of the given one, like the same zone loaded from another server.
+func (bs *BindService) ImportZone(source *parser.ZoneConf) (*parser.ZoneConf, error) {
+	zConf := &parser.ZoneConf{
+		Origin:    source.Origin,
+		Ttl:       source.Ttl,
+		SOARecord: source.SOARecord,
+		Records:   source.Records,
+		Other:     source.Other,
+	}
+
+	return bs.createZone(zConf, nil)
+}
+
+// Writes the new zone, with its file in the zones directory, and adds it to BIND and to the catalog groups.
+func (bs *BindService) createZone(zConf *parser.ZoneConf, groups []string) (*parser.ZoneConf, error) {
+	// Get write access to the filesystem and release it when done
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	// Validate that the new zone is not defined already
+	if _, ok := bs.zone(zConf.Origin); ok {
+		return nil, fmt.Errorf("zone %s exists already", zConf.Origin)
+	}
+
+	zConf.File = path.Join(bs.zonesDir(), "db."+zConf.Origin)
+
+	filename, err := bs.resolvePath(zConf.File)
+	if err != nil {
+		return nil, err
+	}
+
 	bindConf := *bs.BindConf
 
 	if err := bindConf.AddZone(zConf); err != nil {
 		return nil, err
 	}
 
+	// With allow-new-zones the zone is added to BIND alone, named.conf.local is left untouched
+	zone := bindConf.GetZone(zConf.Origin)
+	zone.Added = bs.Setting.AllowNewZones
+
+	// Check the candidate files, with their new serial, before the live ones are replaced
+	zConf.UpdateSerial()
+
+	if err := bs.checkZone(zConf.Origin, filename, zConf); err != nil {
+		return nil, err
+	}
+
+	if !zone.Added {
+		if err := bs.checkConf(&bindConf, bs.OptionsConf); err != nil {
+			return nil, err
+		}
+	}
+
 	// Write new changes to BIND files and rollback on error
-	rollbackZConf, err := zConf.WriteToDisk(zConf.GetFilename())
+	tx, err := bs.begin()
 	if err != nil {
-		rollbackZConf()
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	rollbackBindConf, err := bindConf.WriteToDisk(bs.ZonesFilePath)
+	if err := zConf.WriteToDisk(tx, filename); err != nil {
+		return nil, err
+	}
+
+	if !zone.Added {
+		if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+			return nil, err
+		}
+	}
+
+	catalog, err := bs.writeCatalog(tx, func(catalog *parser.CatalogZone) {
+		catalog.SetMember(zConf.Origin, groups)
+	})
 	if err != nil {
-		rollbackZConf()
-		rollbackBindConf()
 		return nil, err
 	}
 
 	// Notify BIND about the new update
-	if err := bs.Reconfig(); err != nil {
-		rollbackZConf()
-		rollbackBindConf()
+	if zone.Added {
+		if err := bs.addZone(zone); err != nil {
+			return nil, err
+		}
+	} else if err := bs.Reconfig(); err != nil {
+		return nil, err
+	}
+
+	if err := bs.reloadCatalog(catalog); err != nil {
+		// The file of the zone is removed on rollback, so BIND must not keep serving it
+		if zone.Added {
+			if err := bs.delZone(zone.Name); err != nil {
+				log.Printf("Error deleting zone %s after a failed creation: %s\n", zone.Name, err)
+			}
+		}
 		return nil, err
 	}
 
+	tx.Commit()
+
 	// Sync changes on memory
 	bs.BindConf = &bindConf
-	bs.Zones[zConf.Origin] = zConf
+	bs.setZone(zConf.Origin, zConf)
+	if catalog != nil {
+		bs.Catalog = catalog
+	}
 
 	return zConf, nil
 }
 
 func (bs *BindService) UpdateZone(targetOrigin string, data *schemas.ZoneData) (*parser.ZoneConf, error) {
-	bs.Mutex.Lock()
-	defer bs.Mutex.Unlock()
+	// The catalog is shared by every zone
+	if data.Groups != nil {
+		bs.Mutex.Lock()
+		defer bs.Mutex.Unlock()
+	} else {
+		unlock := bs.lockZone(targetOrigin)
+		defer unlock()
+	}
 
-	zConfPointer, ok := bs.Zones[targetOrigin]
+	zConfPointer, ok := bs.zone(targetOrigin)
 	if !ok {
 		return nil, fmt.Errorf("zone %s does not exist", targetOrigin)
 	}
 
+	// BIND keeps the file of zones changed with dynamic updates, their new SOA record is sent as an update
+	client := bs.updateClient(targetOrigin)
+
+	var edit *zoneEdit
+	if client == nil {
+		var err error
+		edit, err = bs.editZone(zConfPointer)
+		if err != nil {
+			return nil, err
+		}
+		defer edit.thaw()
+
+		zConfPointer = edit.zConf
+	}
+
+	previous := zConfPointer.Validate()
 	ZConf := *zConfPointer
+	soa := *ZConf.SOARecord
 
 	ZConf.Ttl = data.Ttl
-	ZConf.SOARecord.NameServer = data.NameServer
-	ZConf.SOARecord.Admin = data.Admin
-	ZConf.SOARecord.Refresh = data.Refresh
-	ZConf.SOARecord.Retry = data.Retry
-	ZConf.SOARecord.Expire = data.Expire
-	ZConf.SOARecord.Minimum = data.Minimum
-
-	rollback, err := ZConf.WriteToDisk(ZConf.GetFilename())
+	soa.NameServer = data.NameServer
+	soa.Admin = data.Admin
+	soa.Refresh = data.Refresh
+	soa.Retry = data.Retry
+	soa.Expire = data.Expire
+	soa.Minimum = data.Minimum
+	ZConf.SOARecord = &soa
+
+	if err := bs.validateZone(previous, &ZConf); err != nil {
+		return nil, err
+	}
+
+	ZConf.UpdateSerial()
+
+	filename, err := bs.resolvePath(ZConf.File)
 	if err != nil {
-		rollback()
 		return nil, err
 	}
 
-	if err := bs.ReloadZone(targetOrigin); err != nil {
-		rollback()
+	if client == nil {
+		if err := bs.checkZone(targetOrigin, filename, &ZConf); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	var catalog *parser.CatalogZone
+	if data.Groups != nil {
+		catalog, err = bs.writeCatalog(tx, func(catalog *parser.CatalogZone) {
+			catalog.SetMember(targetOrigin, data.Groups)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if client != nil {
+		// The TTL of the SOA record is the default TTL of the zone loaded from a transfer. Records of the
+		// zone take the new TTL too, like the records without a TTL of their own in a zone file.
+		insert := []parser.Record{ZConf.SOARecord}
+		if ZConf.Ttl != zConfPointer.Ttl {
+			insert = append(insert, ZConf.Records...)
+		}
+
+		if err := bs.sendUpdate(client, &ZConf, nil, insert); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := ZConf.WriteToDisk(tx, filename); err != nil {
+			return nil, err
+		}
+
+		if err := edit.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	if catalog != nil {
+		if err := bs.reloadCatalog(catalog); err != nil {
+			return nil, err
+		}
+	}
 
-	bs.Zones[targetOrigin] = &ZConf
+	tx.Commit()
+
+	if catalog != nil {
+		bs.Catalog = catalog
+	}
+
+	// Zones changed with dynamic updates were already replaced with their records as served by BIND
+	if client != nil {
+		zConf, _ := bs.zone(targetOrigin)
+		return zConf, nil
+	}
+
+	bs.setZone(targetOrigin, &ZConf)
 
 	return &ZConf, nil
 }
@@ -196,185 +586,310 @@ func (bs *BindService) DeleteZone(origin string) error {
 	bs.Mutex.Lock()
 	defer bs.Mutex.Unlock()
 
-	targetZConf, ok := bs.Zones[origin]
+	targetZConf, ok := bs.zone(origin)
 	if !ok {
 		return fmt.Errorf("domain %s does not exist", origin)
 	}
 
-	rollbackDConf, err := targetZConf.DeleteFromDisk(targetZConf.GetFilename())
+	filename, err := bs.resolvePath(targetZConf.File)
 	if err != nil {
-		rollbackDConf()
 		return err
 	}
 
+	// Zones added with rndc addzone are deleted from BIND alone, they are not in named.conf.local
+	zone := bs.BindConf.GetZone(origin)
+	added := zone != nil && zone.Added
+
 	bindConf := *bs.BindConf
 
 	if err := bindConf.DeleteZone(targetZConf); err != nil {
-		rollbackDConf()
 		return err
 	}
 
-	rollbackBindConf, err := bindConf.WriteToDisk(bs.ZonesFilePath)
+	if !added {
+		if err := bs.checkConf(&bindConf, bs.OptionsConf); err != nil {
+			return err
+		}
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := targetZConf.DeleteFromDisk(tx, filename); err != nil {
+		return err
+	}
+
+	if !added {
+		if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+			return err
+		}
+	}
+
+	catalog, err := bs.writeCatalog(tx, func(catalog *parser.CatalogZone) {
+		catalog.RemoveMember(origin)
+	})
 	if err != nil {
-		rollbackDConf()
-		rollbackBindConf()
 		return err
 	}
 
-	if err := bs.Reconfig(); err != nil {
-		rollbackDConf()
-		rollbackBindConf()
+	if added {
+		if err := bs.delZone(origin); err != nil {
+			return err
+		}
+	} else if err := bs.Reconfig(); err != nil {
 		return err
 	}
 
+	if err := bs.reloadCatalog(catalog); err != nil {
+		// The file of the zone is restored on rollback, so BIND serves it again
+		if added {
+			if err := bs.addZone(zone); err != nil {
+				log.Printf("Error adding zone %s back after a failed deletion: %s\n", origin, err)
+			}
+		}
+		return err
+	}
+
+	tx.Commit()
+	bs.removeJournal(filename)
+
 	bs.BindConf = &bindConf
-	delete(bs.Zones, origin)
+	bs.setZone(origin, nil)
+	if catalog != nil {
+		bs.Catalog = catalog
+	}
 
 	return nil
 }
 
+// Record changes are queued in write-behind mode, these wait until they are applied.
+
 func (bs *BindService) AddRecord(origin string, record parser.Record) error {
-	bs.Mutex.Lock()
-	defer bs.Mutex.Unlock()
+	return bs.waitChange(bs.addRecord(origin, record))
+}
+
+func (bs *BindService) UpdateRecord(origin, target string, record parser.Record) error {
+	return bs.waitChange(bs.updateRecord(origin, target, record))
+}
+
+func (bs *BindService) DeleteRecord(origin string, record parser.Record) error {
+	return bs.waitChange(bs.deleteRecord(origin, record))
+}
+
+// Returns true if the record changes of the zone are queued in write-behind mode.
+// Changes sent as dynamic updates are applied right away.
+func (bs *BindService) queued(origin string) bool {
+	return bs.queue != nil && bs.updateClient(origin) == nil
+}
+
+// Applies the record change, or queues it in write-behind mode. The change is nil if it was applied.
+func (bs *BindService) addRecord(origin string, record parser.Record) (*queuedChange, error) {
+	unlock := bs.lockZone(origin)
+	defer unlock()
 
-	targetZConf, ok := bs.Zones[origin]
+	targetZConf, ok := bs.zone(origin)
 	if !ok {
-		return errors.New("origin not found")
+		return nil, errors.New("origin not found")
 	}
 
-	zConf := *targetZConf
-
-	if err := zConf.AddRecord(record); err != nil {
-		return err
+	if bs.queued(origin) {
+		return bs.queueChange(targetZConf, func(zConf *parser.ZoneConf) error {
+			return zConf.AddRecord(record)
+		})
 	}
 
-	rollback, err := zConf.WriteToDisk(zConf.GetFilename())
+	edit, err := bs.editZone(targetZConf)
 	if err != nil {
-		rollback()
-		return err
+		return nil, err
 	}
+	defer edit.thaw()
 
-	if err := bs.ReloadZone(origin); err != nil {
-		rollback()
-		return err
+	previous := edit.zConf.Validate()
+	zConf := *edit.zConf
+
+	if err := zConf.AddRecord(record); err != nil {
+		return nil, err
 	}
 
-	bs.Zones[origin] = &zConf
+	if err := bs.validateZone(previous, &zConf); err != nil {
+		return nil, err
+	}
 
-	return nil
+	if client := bs.updateClient(origin); client != nil {
+		return nil, bs.sendUpdate(client, &zConf, nil, []parser.Record{record})
+	}
+
+	return nil, bs.writeZone(edit, &zConf)
 }
 
-func (bs *BindService) UpdateRecord(origin, target string, record parser.Record) error {
-	bs.Mutex.Lock()
-	defer bs.Mutex.Unlock()
+func (bs *BindService) updateRecord(origin, target string, record parser.Record) (*queuedChange, error) {
+	unlock := bs.lockZone(origin)
+	defer unlock()
 
-	targetZConf, ok := bs.Zones[origin]
+	targetZConf, ok := bs.zone(origin)
 	if !ok {
-		return errors.New("origin not found")
+		return nil, errors.New("origin not found")
 	}
 
-	zConf := *targetZConf
-
-	if err := zConf.UpdateRecord(target, record); err != nil {
-		return err
+	if bs.queued(origin) {
+		return bs.queueChange(targetZConf, func(zConf *parser.ZoneConf) error {
+			return zConf.UpdateRecord(target, record)
+		})
 	}
 
-	rollback, err := zConf.WriteToDisk(zConf.GetFilename())
+	edit, err := bs.editZone(targetZConf)
 	if err != nil {
-		rollback()
-		return err
+		return nil, err
 	}
+	defer edit.thaw()
 
-	if err := bs.ReloadZone(origin); err != nil {
-		rollback()
-		return err
+	previous := edit.zConf.Validate()
+
+	var replaced parser.Record
+	if index := edit.zConf.GetRecordStringIndex(target); index != -1 {
+		replaced = edit.zConf.Records[index]
 	}
 
-	bs.Zones[origin] = &zConf
+	zConf := *edit.zConf
 
-	return nil
+	if err := zConf.UpdateRecord(target, record); err != nil {
+		return nil, err
+	}
+
+	if err := bs.validateZone(previous, &zConf); err != nil {
+		return nil, err
+	}
+
+	if client := bs.updateClient(origin); client != nil {
+		return nil, bs.sendUpdate(client, &zConf, []parser.Record{replaced}, []parser.Record{record})
+	}
+
+	return nil, bs.writeZone(edit, &zConf)
 }
 
-func (bs *BindService) DeleteRecord(origin string, record parser.Record) error {
-	bs.Mutex.Lock()
-	defer bs.Mutex.Unlock()
+func (bs *BindService) deleteRecord(origin string, record parser.Record) (*queuedChange, error) {
+	unlock := bs.lockZone(origin)
+	defer unlock()
 
-	targetZConf, ok := bs.Zones[origin]
+	targetZConf, ok := bs.zone(origin)
 	if !ok {
-		return errors.New("origin not found")
+		return nil, errors.New("origin not found")
 	}
 
-	zConf := *targetZConf
-
-	if err := zConf.DeleteRecord(record); err != nil {
-		return err
+	if bs.queued(origin) {
+		return bs.queueChange(targetZConf, func(zConf *parser.ZoneConf) error {
+			return zConf.DeleteRecord(record)
+		})
 	}
 
-	rollback, err := zConf.WriteToDisk(zConf.GetFilename())
+	edit, err := bs.editZone(targetZConf)
 	if err != nil {
-		rollback()
-		return err
+		return nil, err
 	}
+	defer edit.thaw()
 
-	if err := bs.ReloadZone(origin); err != nil {
-		rollback()
-		return err
+	previous := edit.zConf.Validate()
+	zConf := *edit.zConf
+
+	if err := zConf.DeleteRecord(record); err != nil {
+		return nil, err
 	}
 
-	bs.Zones[origin] = &zConf
+	if err := bs.validateZone(previous, &zConf); err != nil {
+		return nil, err
+	}
 
-	return nil
+	if client := bs.updateClient(origin); client != nil {
+		return nil, bs.sendUpdate(client, &zConf, []parser.Record{record}, nil)
+	}
+
+	return nil, bs.writeZone(edit, &zConf)
 }
 
-func (bs *BindService) exec(command ...string) error {
-	// was used as reference for this method the docker-cli exec command implementation
-	// https://github.com/docker/cli/blob/1163b4609978e0e6f2b2629b59c4a62d348e1466/cli/command/container/exec.go#L99
+// Writes the file of the edited zone and makes BIND load it, keeping the zone in memory on success.
+func (bs *BindService) writeZone(edit *zoneEdit, zConf *parser.ZoneConf) error {
+	// Zones changed with dynamic updates are only known from a transfer, which leaves records out
+	if bs.updateClient(zConf.Origin) != nil {
+		return fmt.Errorf("zone %s receives its changes as dynamic updates, its file can not be written", zConf.Origin)
+	}
 
-	if _, err := bs.DockerCli.ContainerInspect(bs.ctx, bs.ContainerId); err != nil {
+	filename, err := bs.resolvePath(zConf.File)
+	if err != nil {
 		return err
 	}
 
-	execCreateConfig := &types.ExecConfig{
-		User:         "bind",
-		Privileged:   false,
-		Tty:          false,
-		AttachStdin:  false,
-		AttachStderr: true,
-		AttachStdout: false,
-		Detach:       false,
-		DetachKeys:   "",
-		Env:          []string{},
-		WorkingDir:   "/",
-		Cmd:          command,
+	zConf.UpdateSerial()
+
+	if err := bs.checkZone(zConf.Origin, filename, zConf); err != nil {
+		return err
 	}
 
-	response, err := bs.DockerCli.ContainerExecCreate(bs.ctx, bs.ContainerId, *execCreateConfig)
+	tx, err := bs.begin()
 	if err != nil {
 		return err
 	}
-	if response.ID == "" {
-		return errors.New("exec ID empty")
+	defer tx.Rollback()
+
+	if edit.onCommit != nil {
+		tx.OnCommit = edit.onCommit
 	}
 
-	execStartConfig := &types.ExecStartCheck{
-		Detach: execCreateConfig.Detach,
-		Tty:    execCreateConfig.Tty,
+	if err := zConf.WriteToDisk(tx, filename); err != nil {
+		return err
 	}
 
-	if err := bs.DockerCli.ContainerExecStart(bs.ctx, response.ID, *execStartConfig); err != nil {
+	if err := edit.reload(); err != nil {
 		return err
 	}
 
+	tx.Commit()
+
+	bs.setZone(zConf.Origin, zConf)
+
 	return nil
 }
 
+// Runs a command where BIND is installed and returns its standard output.
+func (bs *BindService) exec(command ...string) (string, error) {
+	return bs.Runner.Run(command...)
+}
+
 // Runs `rndc reconfig` in the BIND server.
 // Reloads the configuration file and loads new zones, but does not reload existing zone files even if they have changed.
 func (bs *BindService) Reconfig() error {
-	return bs.exec("rndc", "reconfig")
+	_, err := bs.control("reconfig")
+	return err
+}
+
+// Reloads a zone, or reconfigures BIND if origin is empty.
+func (bs *BindService) Reload(origin string) error {
+	if origin == "" {
+		bs.Mutex.Lock()
+		defer bs.Mutex.Unlock()
+
+		return bs.Reconfig()
+	}
+
+	unlock := bs.lockZone(origin)
+	defer unlock()
+
+	if _, ok := bs.zone(origin); !ok {
+		return fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	return bs.ReloadZone(origin)
+}
+
+// Runs `rndc status` in the BIND server.
+func (bs *BindService) Status() (string, error) {
+	return bs.control("status")
 }
 
 // Runs `rndc reload {zone}` in the BIND server
 func (bs *BindService) ReloadZone(zone string) error {
-	return bs.exec("rndc", "reload", zone)
+	_, err := bs.control("reload", zone)
+	return err
 }
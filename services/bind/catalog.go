@@ -0,0 +1,162 @@
+package bind
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/svex99/bind-api/pkg/file"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Loads the catalog zone, adding it to the configuration with every primary zone as member
+// if it does not exist yet. Secondaries consume it with a `catalog-zones` option.
+func (bs *BindService) loadCatalog() error {
+	origin := bs.Setting.CatalogZone
+
+	if zone := bs.BindConf.GetZone(origin); zone != nil {
+		filename, err := bs.resolvePath(zone.File)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		catalog, err := parser.ParseCatalogZone(origin, string(content))
+		if err != nil {
+			return err
+		}
+		bs.Catalog = catalog
+
+		fmt.Printf(">>> Loaded catalog zone %s with %d member(s)\n", origin, len(catalog.Members))
+
+		return nil
+	}
+
+	catalog := &parser.CatalogZone{Origin: origin, Members: []*parser.CatalogMember{}}
+
+	origins := []string{}
+	for zoneOrigin := range bs.zones() {
+		origins = append(origins, zoneOrigin)
+	}
+	sort.Strings(origins)
+
+	for _, zoneOrigin := range origins {
+		catalog.SetMember(zoneOrigin, nil)
+	}
+
+	allowTransfer := []*parser.AddressMatch{}
+	for _, secondary := range bs.Setting.CatalogSecondaries {
+		allowTransfer = append(allowTransfer, &parser.AddressMatch{Value: secondary})
+	}
+
+	zone := &parser.Zone{
+		Name:          origin,
+		Type:          "master",
+		File:          path.Join(bs.zonesDir(), "db."+origin),
+		AllowTransfer: allowTransfer,
+		AlsoNotify:    bs.Setting.CatalogSecondaries,
+	}
+
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return err
+	}
+
+	bindConf := *bs.BindConf
+	bindConf.Zones = append(append([]*parser.Zone{}, bs.BindConf.Zones...), zone)
+
+	catalog.UpdateSerial()
+
+	if err := bs.checkZone(origin, filename, catalog); err != nil {
+		return err
+	}
+
+	if err := bs.checkConf(&bindConf, bs.OptionsConf); err != nil {
+		return err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := catalog.WriteToDisk(tx, filename); err != nil {
+		return err
+	}
+
+	if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+		return err
+	}
+
+	if err := bs.Reconfig(); err != nil {
+		return err
+	}
+
+	tx.Commit()
+
+	bs.BindConf = &bindConf
+	bs.Catalog = catalog
+
+	fmt.Printf(">>> Created catalog zone %s with %d member(s)\n", origin, len(catalog.Members))
+
+	return nil
+}
+
+// Writes a copy of the catalog with the changes applied by `update` as part of the transaction.
+// Does nothing if the catalog zone is disabled.
+func (bs *BindService) writeCatalog(tx *file.Transaction, update func(catalog *parser.CatalogZone)) (*parser.CatalogZone, error) {
+	if bs.Catalog == nil {
+		return nil, nil
+	}
+
+	catalog := *bs.Catalog
+	update(&catalog)
+
+	zone := bs.BindConf.GetZone(catalog.Origin)
+	if zone == nil {
+		return nil, fmt.Errorf("catalog zone %s is not configured", catalog.Origin)
+	}
+
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog.UpdateSerial()
+
+	if err := bs.checkZone(catalog.Origin, filename, &catalog); err != nil {
+		return nil, err
+	}
+
+	if err := catalog.WriteToDisk(tx, filename); err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+// Reloads the catalog zone so secondaries are notified about its changes.
+func (bs *BindService) reloadCatalog(catalog *parser.CatalogZone) error {
+	if catalog == nil {
+		return nil
+	}
+
+	return bs.ReloadZone(catalog.Origin)
+}
+
+func (bs *BindService) GetCatalog() (*parser.CatalogZone, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	if bs.Catalog == nil {
+		return nil, fmt.Errorf("catalog zone is disabled")
+	}
+
+	return bs.Catalog, nil
+}
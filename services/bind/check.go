@@ -0,0 +1,137 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Candidate files are written next to the live ones with this suffix, so the BIND tools can read them.
+const checkSuffix = ".check"
+
+// Error of a pre-flight check, with the diagnostics reported by the BIND tool.
+type CheckError struct {
+	Command     string
+	Diagnostics string
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Command, e.Diagnostics)
+}
+
+// Runs named-checkzone on the candidate content of a zone before it replaces the live file.
+func (bs *BindService) checkZone(origin, filename string, zone fmt.Stringer) error {
+	if bs.Setting.SkipChecks {
+		return nil
+	}
+
+	candidate := filename + checkSuffix
+
+	bindPath, err := bs.PathMap.BindPath(candidate)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(candidate, []byte(zone.String()), 0666); err != nil {
+		return err
+	}
+	defer os.Remove(candidate)
+
+	return bs.runCheck("named-checkzone", origin, bindPath)
+}
+
+// Runs named-checkconf on the candidate options and zones configuration before they replace the live files.
+// The candidates are checked through a candidate named.conf, the live one with its includes of the options
+// and zones files pointing to them, so the rest of the configuration, like rndc.key or the default zones,
+// is checked along.
+func (bs *BindService) checkConf(bindConf *parser.BindConf, optionsConf *parser.StatementsFile) error {
+	if bs.Setting.SkipChecks {
+		return nil
+	}
+
+	namedConf := bs.Setting.ConfPath + "named.conf"
+
+	content, err := os.ReadFile(namedConf)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	statementsFile, err := parser.StatementParser.ParseString(namedConf, string(content))
+	if err != nil {
+		return err
+	}
+
+	live := []struct{ filename, content string }{
+		{bs.OptionsFilePath, optionsConf.String()},
+		{bs.ZonesFilePath, bindConf.String()},
+	}
+
+	// BIND paths of the candidates by the name of the live file they replace
+	names := []string{}
+	candidates := map[string]string{}
+
+	for _, conf := range live {
+		candidate := conf.filename + checkSuffix
+
+		bindPath, err := bs.PathMap.BindPath(candidate)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(candidate, []byte(conf.content), 0666); err != nil {
+			return err
+		}
+		defer os.Remove(candidate)
+
+		names = append(names, path.Base(conf.filename))
+		candidates[path.Base(conf.filename)] = bindPath
+	}
+
+	for _, statement := range statementsFile.Statements {
+		if values := statement.Values(); statement.Name() == "include" && len(values) > 0 {
+			name := path.Base(parser.Unquote(values[0]))
+			if bindPath, ok := candidates[name]; ok {
+				statement.Args[len(statement.Args)-1].Value = parser.Quote(bindPath)
+				delete(candidates, name)
+			}
+		}
+	}
+
+	// Candidates of the files not included by named.conf, or of both if it does not exist, are included at the end
+	for _, name := range names {
+		if bindPath, ok := candidates[name]; ok {
+			statementsFile.Statements = append(statementsFile.Statements, parser.NewStatement("include", parser.Quote(bindPath)))
+		}
+	}
+
+	candidate := namedConf + checkSuffix
+
+	bindPath, err := bs.PathMap.BindPath(candidate)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(candidate, []byte(statementsFile.String()), 0666); err != nil {
+		return err
+	}
+	defer os.Remove(candidate)
+
+	return bs.runCheck("named-checkconf", bindPath)
+}
+
+func (bs *BindService) runCheck(command ...string) error {
+	output, err := bs.exec(command...)
+
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		// named-checkzone reports problems in the standard output and named-checkconf in the error output
+		diagnostics := strings.TrimSpace(strings.TrimSpace(output) + "\n" + strings.TrimSpace(cmdErr.Stderr))
+		return &CheckError{Command: command[0], Diagnostics: diagnostics}
+	}
+
+	return err
+}
@@ -0,0 +1,72 @@
+package bind_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestCheckedContentIsWritten(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records:   []parser.Record{parser.NSRecord{Type: "NS", NameServer: "ns1"}},
+	}
+
+	bindService, runner := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.SkipChecks = false
+	})
+
+	assert.Nil(t, bindService.AddRecord("example.com", parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"}))
+
+	content, _ := os.ReadFile(bindService.Setting.LibPath + "db.example.com")
+	if checked := runner.Checked(); assert.Len(t, checked, 1) {
+		assert.Equal(t, checked[0], string(content))
+	}
+	assert.NotContains(t, string(content), "( 1 3600")
+}
+
+func TestCheckedConfIncludesCandidates(t *testing.T) {
+	bindService, runner := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf": "include \"/etc/bind/named.conf.options\";\n" +
+			"include \"/etc/bind/named.conf.local\";\n" +
+			"include \"/etc/bind/named.conf.default-zones\";\n",
+		"/etc/bind/named.conf.local":   "",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.SkipChecks = false
+	})
+
+	_, err := bindService.CreateKey(&schemas.KeyData{Name: "update", Algorithm: "hmac-sha256"})
+	assert.Nil(t, err)
+
+	// The rest of named.conf is checked along with the candidate files
+	assert.Equal(t, []string{"named-checkconf /etc/bind/named.conf.check", "rndc reconfig"}, runner.Commands())
+	if checked := runner.Checked(); assert.Len(t, checked, 1) {
+		assert.Equal(t, "include \"/etc/bind/named.conf.options.check\";\n"+
+			"include \"/etc/bind/named.conf.local.check\";\n"+
+			"include \"/etc/bind/named.conf.default-zones\";\n", checked[0])
+	}
+	assert.NoFileExists(t, bindService.Setting.ConfPath+"named.conf.check")
+
+	// Without named.conf the candidate files are checked alone
+	assert.Nil(t, os.Remove(bindService.Setting.ConfPath+"named.conf"))
+
+	_, err = bindService.RotateKey("update")
+	assert.Nil(t, err)
+
+	if checked := runner.Checked(); assert.Len(t, checked, 2) {
+		assert.Equal(t, "include \"/etc/bind/named.conf.options.check\";\n\n"+
+			"include \"/etc/bind/named.conf.local.check\";\n", checked[1])
+	}
+}
@@ -0,0 +1,85 @@
+package bind
+
+import (
+	"fmt"
+
+	"github.com/svex99/bind-api/schemas"
+)
+
+// Policies provided by BIND that do not need to be defined in the configuration.
+var builtinPolicies = []string{"default", "insecure", "none"}
+
+func (bs *BindService) isPolicyDefined(policy string) bool {
+	policies := append(builtinPolicies, bs.BindConf.DnssecPolicies()...)
+	policies = append(policies, bs.OptionsConf.DnssecPolicies()...)
+
+	for _, p := range policies {
+		if p == policy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the DNSSEC settings of a zone and its signing status as reported by BIND.
+func (bs *BindService) GetZoneDnssec(origin string) (*schemas.DnssecStatus, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	zone := bs.BindConf.GetZone(origin)
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	dnssecStatus := &schemas.DnssecStatus{
+		Policy:        zone.DnssecPolicy,
+		InlineSigning: zone.InlineSigning,
+	}
+
+	if zone.DnssecPolicy != "" {
+		status, err := bs.DnssecStatus(origin)
+		if err != nil {
+			return nil, err
+		}
+		dnssecStatus.Status = status
+	}
+
+	return dnssecStatus, nil
+}
+
+// Sets the DNSSEC policy of a zone and reconfigures BIND so it starts signing it.
+func (bs *BindService) SetZoneDnssec(origin string, data *schemas.DnssecData) (*schemas.DnssecStatus, error) {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	targetZone := bs.BindConf.GetZone(origin)
+	if targetZone == nil || !targetZone.IsPrimary() {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	if data.Policy != "" && !bs.isPolicyDefined(data.Policy) {
+		return nil, fmt.Errorf("dnssec-policy %s is not defined", data.Policy)
+	}
+
+	zone := *targetZone
+	zone.DnssecPolicy = data.Policy
+	zone.InlineSigning = data.InlineSigning
+
+	bindConf := *bs.BindConf
+
+	if err := bindConf.UpdateZone(&zone); err != nil {
+		return nil, err
+	}
+
+	if err := bs.applyBindConf(&bindConf); err != nil {
+		return nil, err
+	}
+
+	return &schemas.DnssecStatus{Policy: zone.DnssecPolicy, InlineSigning: zone.InlineSigning}, nil
+}
+
+// Runs `rndc dnssec -status {zone}` in the BIND server and returns its output.
+func (bs *BindService) DnssecStatus(zone string) (string, error) {
+	return bs.control("dnssec", "-status", zone)
+}
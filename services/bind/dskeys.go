@@ -0,0 +1,201 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/svex99/bind-api/pkg/dnssec"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Digest types of the DS records returned when none is requested.
+var defaultDigests = []uint8{dnssec.DigestSHA256, dnssec.DigestSHA384}
+
+// Files of a DNSSEC key of a zone.
+type keyFiles struct {
+	key   string
+	state string
+	// DS records computed by dnssec-dsfromkey, nil when the DS is computed by the API
+	ds []*dnssec.DS
+}
+
+// Returns the directory, as seen by BIND, where the DNSSEC keys of a zone are stored.
+// Defaults to the `directory` option, like BIND does.
+func (bs *BindService) keyDirectory(zone *parser.Zone) string {
+	options := bs.OptionsConf.GetOptions()
+
+	dir := options.Directory
+	if dir == "" {
+		dir = "/var/cache/bind"
+	}
+
+	keyDir := zone.KeyDirectory
+	if keyDir == "" {
+		keyDir = options.KeyDirectory
+	}
+
+	if keyDir == "" {
+		return dir
+	} else if !path.IsAbs(keyDir) {
+		return path.Join(dir, keyDir)
+	}
+
+	return keyDir
+}
+
+// Reads the key files of a zone directly when the key directory is mapped to the API,
+// or from the BIND container using dnssec-dsfromkey otherwise.
+func (bs *BindService) readKeyFiles(origin, dir string, digests []uint8) ([]*keyFiles, error) {
+	prefix := "K" + origin + ".+"
+
+	localDir, err := bs.PathMap.Resolve(dir, "")
+	if err == nil {
+		entries, err := os.ReadDir(localDir)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := []*keyFiles{}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".key") {
+				continue
+			}
+
+			key, err := os.ReadFile(path.Join(localDir, name))
+			if err != nil {
+				return nil, err
+			}
+
+			// Keys not managed by a dnssec-policy have no state file
+			state, _ := os.ReadFile(path.Join(localDir, strings.TrimSuffix(name, ".key")+".state"))
+
+			keys = append(keys, &keyFiles{key: string(key), state: string(state)})
+		}
+
+		return keys, nil
+	}
+
+	found, err := bs.exec("find", dir, "-maxdepth", "1", "-name", prefix+"*.key")
+	if err != nil {
+		return nil, err
+	}
+
+	dsfromkey := []string{"dnssec-dsfromkey"}
+	for _, digest := range digests {
+		dsfromkey = append(dsfromkey, "-a", dnssec.DigestNames[digest])
+	}
+
+	keys := []*keyFiles{}
+
+	for _, filename := range strings.Fields(found) {
+		key, err := bs.exec("cat", filename)
+		if err != nil {
+			return nil, err
+		}
+
+		// Keys not managed by a dnssec-policy have no state file
+		state, err := bs.exec("cat", strings.TrimSuffix(filename, ".key")+".state")
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) {
+			state = ""
+		} else if err != nil {
+			return nil, err
+		}
+
+		output, err := bs.exec(append(dsfromkey, filename)...)
+		if err != nil {
+			return nil, err
+		}
+
+		dsRecords := []*dnssec.DS{}
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			if line == "" {
+				continue
+			}
+
+			ds, err := dnssec.ParseDS(line)
+			if err != nil {
+				return nil, err
+			}
+
+			dsRecords = append(dsRecords, ds)
+		}
+
+		keys = append(keys, &keyFiles{key: key, state: state, ds: dsRecords})
+	}
+
+	return keys, nil
+}
+
+// Returns the DNSSEC keys of a zone with their states and the DS records of its KSKs,
+// listing apart the keys with a rollover that needs an update in the parent zone.
+func (bs *BindService) GetZoneKeys(origin string, digests []uint8) (*schemas.ZoneKeys, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	zone := bs.BindConf.GetZone(origin)
+	if zone == nil {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	if len(digests) == 0 {
+		digests = defaultDigests
+	}
+
+	files, err := bs.readKeyFiles(origin, bs.keyDirectory(zone), digests)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneKeys := &schemas.ZoneKeys{Keys: []*schemas.DnssecKey{}, Pending: []*schemas.DnssecKey{}}
+
+	for _, f := range files {
+		dnskey, err := dnssec.ParseKeyFile(f.key)
+		if err != nil {
+			return nil, err
+		}
+
+		state := dnssec.ParseStateFile(f.state)
+
+		key := &schemas.DnssecKey{
+			KeyTag:       dnskey.KeyTag(),
+			Algorithm:    dnskey.Algorithm,
+			Role:         "ZSK",
+			State:        state,
+			DS:           []*dnssec.DS{},
+			ParentAction: state.ParentAction(),
+		}
+
+		if dnskey.IsKSK() {
+			key.Role = "KSK"
+			if state["ZSK"] == "yes" {
+				key.Role = "CSK"
+			}
+
+			if f.ds != nil {
+				key.DS = f.ds
+			} else {
+				for _, digest := range digests {
+					ds, err := dnskey.DS(digest)
+					if err != nil {
+						return nil, err
+					}
+					key.DS = append(key.DS, ds)
+				}
+			}
+		}
+
+		zoneKeys.Keys = append(zoneKeys.Keys, key)
+		if key.ParentAction != "" {
+			zoneKeys.Pending = append(zoneKeys.Pending, key)
+		}
+	}
+
+	return zoneKeys, nil
+}
@@ -0,0 +1,184 @@
+package bind
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/svex99/bind-api/pkg/ddns"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Returns the name of the key used to send dynamic updates to the zone, or "" if record changes
+// are written to the zone file. Zones use dynamic updates when `allow-update` grants a key known by the API.
+func (bs *BindService) updateKey(zone *parser.Zone) string {
+	for _, match := range zone.AllowUpdate {
+		if match.Key != "" && !match.Negated && bs.BindConf.GetKey(match.Key) != nil {
+			return match.Key
+		}
+	}
+	return ""
+}
+
+// Returns the client of dynamic updates of the zone, or nil if the zone does not use them.
+func (bs *BindService) updateClient(origin string) *ddns.Client {
+	zone := bs.BindConf.GetZone(origin)
+	if zone == nil {
+		return nil
+	}
+
+	keyName := bs.updateKey(zone)
+	if keyName == "" {
+		return nil
+	}
+
+	address := bs.Setting.DnsAddress
+	if address == "" {
+		address = "127.0.0.1:53"
+	}
+
+	key := bs.BindConf.GetKey(keyName)
+
+	return ddns.NewClient(address, key.Name, key.Algorithm, key.Secret)
+}
+
+func (bs *BindService) GetZoneUpdates(origin string) (*schemas.DynamicUpdateData, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	zone := bs.BindConf.GetZone(origin)
+	if zone == nil || !zone.IsPrimary() {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	return &schemas.DynamicUpdateData{Key: bs.updateKey(zone)}, nil
+}
+
+// Switches the record changes of a zone to dynamic updates signed with the key, or back to
+// zone file edits if the key is empty. The key is also allowed to transfer the zone,
+// since the API reloads the zone with a transfer after each update.
+func (bs *BindService) SetZoneUpdates(origin string, data *schemas.DynamicUpdateData) (*schemas.DynamicUpdateData, error) {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	targetZone := bs.BindConf.GetZone(origin)
+	if targetZone == nil || !targetZone.IsPrimary() {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	if data.Key != "" && bs.BindConf.GetKey(data.Key) == nil {
+		return nil, fmt.Errorf("key %s does not exist", data.Key)
+	}
+
+	zone := *targetZone
+
+	current := bs.updateKey(targetZone)
+	zone.AllowUpdate = withoutKey(zone.AllowUpdate, current)
+	zone.AllowTransfer = withoutKey(zone.AllowTransfer, current)
+
+	if data.Key != "" {
+		zone.AllowUpdate = append(zone.AllowUpdate, &parser.AddressMatch{Key: data.Key})
+		zone.AllowTransfer = append(zone.AllowTransfer, &parser.AddressMatch{Key: data.Key})
+	}
+
+	bindConf := *bs.BindConf
+
+	if err := bindConf.UpdateZone(&zone); err != nil {
+		return nil, err
+	}
+
+	if err := bs.applyBindConf(&bindConf); err != nil {
+		return nil, err
+	}
+
+	if client := bs.updateClient(origin); client != nil {
+		current, _ := bs.zone(origin)
+		if err := bs.refreshZone(client, current); err != nil {
+			log.Printf("Error transferring zone %s: %s\n", origin, err)
+		}
+	}
+
+	return &schemas.DynamicUpdateData{Key: data.Key}, nil
+}
+
+// Returns the list without the elements that grant the key.
+func withoutKey(list []*parser.AddressMatch, key string) []*parser.AddressMatch {
+	elements := []*parser.AddressMatch{}
+	for _, element := range list {
+		if key == "" || element.Key != key || element.Negated {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// Sends the record changes of the zone in a single dynamic update, then reloads the zone with a transfer.
+// `zConf` is the zone with the changes applied, kept if the transfer fails.
+func (bs *BindService) sendUpdate(client *ddns.Client, zConf *parser.ZoneConf, remove, insert []parser.Record) error {
+	removeRRs, err := recordsToRRs(zConf, remove)
+	if err != nil {
+		return err
+	}
+
+	insertRRs, err := recordsToRRs(zConf, insert)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Update(zConf.Origin, removeRRs, insertRRs); err != nil {
+		return err
+	}
+
+	bs.setZone(zConf.Origin, zConf)
+
+	if err := bs.refreshZone(client, zConf); err != nil {
+		log.Printf("Error transferring zone %s after update: %s\n", zConf.Origin, err)
+	}
+
+	return nil
+}
+
+// Replaces the zone in memory with its records as served by BIND.
+func (bs *BindService) refreshZone(client *ddns.Client, current *parser.ZoneConf) error {
+	if current == nil {
+		return nil
+	}
+
+	rrs, err := client.Transfer(current.Origin)
+	if err != nil {
+		return err
+	}
+
+	zConf, err := parser.ZoneFromRRs(current.Origin, rrs)
+	if err != nil {
+		return err
+	}
+	zConf.File = current.File
+
+	bs.setZone(current.Origin, zConf)
+
+	return nil
+}
+
+// Converts records of the API to records of the DNS library, relative names are completed with the origin.
+func recordsToRRs(zConf *parser.ZoneConf, records []parser.Record) ([]dns.RR, error) {
+	content := fmt.Sprintf("$TTL %s\n", zConf.Ttl)
+	for _, record := range records {
+		content += record.String()
+	}
+
+	zoneParser := dns.NewZoneParser(strings.NewReader(content), dns.Fqdn(zConf.Origin), "")
+
+	rrs := []dns.RR{}
+	for rr, ok := zoneParser.Next(); ok; rr, ok = zoneParser.Next() {
+		rrs = append(rrs, rr)
+	}
+
+	if err := zoneParser.Err(); err != nil {
+		return nil, err
+	}
+
+	return rrs, nil
+}
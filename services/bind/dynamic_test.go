@@ -0,0 +1,64 @@
+package bind_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestUpdateZoneWithDynamicUpdates(t *testing.T) {
+	address, served := tests.StubDnsServer(t, []dns.RR{
+		tests.MustRR(t, "example.com. 86400 IN SOA ns1.example.com. admin.example.com. 1 3600 600 86400 60"),
+		tests.MustRR(t, "example.com. 86400 IN NS ns1.example.com."),
+		tests.MustRR(t, "ns1.example.com. 86400 IN A 10.0.0.1"),
+		tests.MustRR(t, "www.example.com. 86400 IN AAAA 2001:db8::2"),
+	})
+
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records: []parser.Record{
+			parser.NSRecord{Type: "NS", NameServer: "ns1"},
+			parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"},
+		},
+	}
+
+	bindService, runner := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local": "key \"" + tests.DdnsKey + "\" {\n\talgorithm hmac-sha256;\n\tsecret \"" + tests.DdnsSecret + "\";\n};\n" +
+			"zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n\tallow-update { key \"" + tests.DdnsKey + "\"; };\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.DnsAddress = address
+	})
+
+	// The new SOA record is sent as an update, the file kept by BIND is not written
+	updated, err := bindService.UpdateZone("example.com", &schemas.ZoneData{
+		Origin: "example.com", Ttl: "2h", NameServer: "ns1", Admin: "admin",
+		Refresh: 7200, Retry: 600, Expire: 86400, Minimum: 60,
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "2h", updated.Ttl)
+		assert.Equal(t, uint(7200), updated.SOARecord.Refresh)
+		assert.Equal(t, []string{"www.example.com.\t86400\tIN\tAAAA\t2001:db8::2"}, updated.Other)
+	}
+
+	records := served()
+	soa := records[0].(*dns.SOA)
+	assert.Equal(t, uint32(7200), soa.Refresh)
+	assert.Equal(t, uint32(7200), soa.Hdr.Ttl)
+	assert.Greater(t, soa.Serial, uint32(1))
+	assert.Equal(t, uint32(7200), records[2].Header().Ttl)
+
+	assert.Empty(t, runner.Commands())
+
+	content, _ := os.ReadFile(bindService.Setting.LibPath + "db.example.com")
+	assert.Equal(t, zConf.String(), string(content))
+}
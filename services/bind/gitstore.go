@@ -0,0 +1,190 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Opens the git store of the BIND directories, or returns nil if it is disabled.
+func openGitStore(bindSetting *setting.BindSetting) (*gitstore.Store, error) {
+	if bindSetting.GitDir == "" {
+		return nil, nil
+	}
+
+	workTree, err := filepath.Abs(bindSetting.ConfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	libPath, err := filepath.Abs(bindSetting.LibPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for !isWithin(libPath, workTree) {
+		workTree = filepath.Dir(workTree)
+	}
+
+	return gitstore.Open(bindSetting.GitDir, workTree)
+}
+
+func isWithin(filename, dir string) bool {
+	rel, err := filepath.Rel(dir, filename)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// Returns the files of the configuration and of the primary zones, as seen by the API.
+// Only the zone files of dynamic zones are returned if `dynamic` is true.
+func (bs *BindService) configFiles(dynamic bool) []string {
+	files := []string{}
+	if !dynamic {
+		files = append(files, bs.ZonesFilePath, bs.OptionsFilePath)
+	}
+
+	for _, zone := range bs.BindConf.Zones {
+		if !zone.IsPrimary() || (dynamic && !zone.IsDynamic()) {
+			continue
+		}
+
+		if filename, err := bs.resolvePath(zone.File); err == nil {
+			files = append(files, filename)
+		}
+	}
+
+	return files
+}
+
+// Zone files of the dynamic zones of a configuration.
+type dynamicFiles struct {
+	bindConf    *parser.BindConf
+	optionsConf *parser.StatementsFile
+	files       map[string]bool
+}
+
+// Returns true if the file is the zone file of a dynamic zone. The files are found once for each
+// configuration, the loaded one or the one that replaced it, so the caller must hold Mutex.
+func (bs *BindService) isDynamicFile(filename string) bool {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	if d := bs.dynamicFiles; d == nil || d.bindConf != bs.BindConf || d.optionsConf != bs.OptionsConf {
+		files := map[string]bool{}
+		for _, dynamicFile := range bs.configFiles(true) {
+			files[filepath.Clean(dynamicFile)] = true
+		}
+		bs.dynamicFiles = &dynamicFiles{bindConf: bs.BindConf, optionsConf: bs.OptionsConf, files: files}
+	}
+
+	return bs.dynamicFiles.files[filename]
+}
+
+// Commits the configuration the first time the git store is used. Afterwards, refuses to continue
+// if files were changed out of the API since the last commit. Zone files of dynamic zones, rewritten
+// by BIND, and the files written while loading are committed instead.
+func (bs *BindService) syncGitStore() error {
+	if bs.Git == nil {
+		return nil
+	}
+
+	change := gitstore.Change{Actor: "bind-api", Endpoint: "startup"}
+
+	if bs.Git.Empty() {
+		change.Message = "Initial configuration"
+		bs.takeChanged()
+		_, err := bs.Git.Commit(bs.configFiles(false), change)
+		return err
+	}
+
+	expected := map[string]bool{}
+	files := append(bs.takeChanged(), bs.configFiles(true)...)
+	for _, filename := range files {
+		if abs, err := filepath.Abs(filename); err == nil {
+			expected[abs] = true
+		}
+	}
+
+	drift, err := bs.Git.Drift()
+	if err != nil {
+		return err
+	}
+
+	unexpected := []string{}
+	for _, filename := range drift {
+		if !expected[filename] {
+			unexpected = append(unexpected, filename)
+		}
+	}
+
+	if len(unexpected) > 0 {
+		return fmt.Errorf(
+			"files changed out of the API, commit or revert them in the git store %s: %s",
+			bs.Git.GitDir, strings.Join(unexpected, ", "),
+		)
+	}
+
+	change.Message = "Files changed by BIND or while loading"
+	_, err = bs.Git.Commit(files, change)
+
+	return err
+}
+
+// Returns a view of the service that keeps the files changed through it, so a request commits
+// the files it changed and no others. A view is meant to be used by a single request.
+func (bs *BindService) WithChanges() backend.Recorder {
+	return &BindService{service: bs.service, changes: &changeSet{}}
+}
+
+// Commits the files changed through the view, or changed since the last commit if the service
+// is not a view. It is called after each API request, so a commit holds the changes of one request.
+func (bs *BindService) RecordChange(change gitstore.Change) error {
+	files := bs.takeChanged()
+
+	if bs.Git == nil || len(files) == 0 {
+		return nil
+	}
+
+	return bs.commitFiles(files, change)
+}
+
+// Commits the files written by a change made out of a request.
+func (bs *BindService) commitFiles(files []string, change gitstore.Change) error {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	_, err := bs.Git.Commit(files, change)
+	return err
+}
+
+// Returns the newest commits of the git store, up to limit, that change the file of a zone
+// or any file if origin is empty.
+func (bs *BindService) ListChanges(origin string, limit int) ([]*gitstore.Commit, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	if bs.Git == nil {
+		return nil, errors.New("git store is disabled")
+	}
+
+	filename := ""
+	if origin != "" {
+		zone := bs.BindConf.GetZone(origin)
+		if zone == nil {
+			return nil, fmt.Errorf("zone %s does not exist", origin)
+		}
+
+		var err error
+		filename, err = bs.resolvePath(zone.File)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bs.Git.Log(filename, limit)
+}
@@ -0,0 +1,298 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/svex99/bind-api/pkg/file"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Returns the file of a zone, or named.conf.local if origin is empty, as its versions are kept in the history.
+func (bs *BindService) historyFile(origin string) (string, error) {
+	if bs.History == nil {
+		return "", errors.New("history is disabled")
+	}
+
+	if origin == "" {
+		return bs.ZonesFilePath, nil
+	}
+
+	zone := bs.BindConf.GetZone(origin)
+	if zone == nil {
+		return "", fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	return bs.resolvePath(zone.File)
+}
+
+// Returns the versions of the file of a zone, or of named.conf.local if origin is empty.
+func (bs *BindService) ListVersions(origin string) ([]*file.Version, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	filename, err := bs.historyFile(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.History.List(filename)
+}
+
+// Returns the differences between two versions of the file of a zone, or of named.conf.local if origin is empty.
+func (bs *BindService) DiffVersions(origin, from, to string) (string, error) {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	filename, err := bs.historyFile(origin)
+	if err != nil {
+		return "", err
+	}
+
+	fromContent, err := bs.History.Read(filename, from)
+	if err != nil {
+		return "", err
+	}
+
+	toContent, err := bs.History.Read(filename, to)
+	if err != nil {
+		return "", err
+	}
+
+	return file.Diff(from, to, fromContent, toContent), nil
+}
+
+// Restores a version of the file of a zone, or of named.conf.local if origin is empty.
+// The version is applied like any other change, so it is validated, checked and reloaded,
+// and becomes the newest version.
+func (bs *BindService) RestoreVersion(origin, id string) error {
+	if origin == "" {
+		bs.Mutex.Lock()
+		defer bs.Mutex.Unlock()
+	} else {
+		unlock := bs.lockZone(origin)
+		defer unlock()
+	}
+
+	filename, err := bs.historyFile(origin)
+	if err != nil {
+		return err
+	}
+
+	content, err := bs.History.Read(filename, id)
+	if err != nil {
+		return err
+	}
+
+	if origin == "" {
+		return bs.restoreBindConf(content)
+	}
+
+	return bs.restoreZone(origin, content)
+}
+
+func (bs *BindService) restoreZone(origin string, content []byte) error {
+	current, ok := bs.zone(origin)
+	if !ok {
+		return fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	if bs.updateClient(origin) != nil {
+		return fmt.Errorf("zone %s receives its changes as dynamic updates, its file can not be restored", origin)
+	}
+
+	edit, err := bs.editZone(current)
+	if err != nil {
+		return err
+	}
+	defer edit.thaw()
+
+	zConf, err := parseZone(current.File, string(content), origin)
+	if err != nil {
+		return err
+	}
+
+	if zConf.Origin != origin {
+		return fmt.Errorf("version is a file of zone %s, not %s", zConf.Origin, origin)
+	}
+
+	// The serial must keep increasing for secondaries to transfer the restored zone
+	zConf.File = edit.zConf.File
+	zConf.SOARecord.Serial = edit.zConf.SOARecord.Serial
+
+	if err := bs.validateZone(edit.zConf.Validate(), zConf); err != nil {
+		return err
+	}
+
+	return bs.writeZone(edit, zConf)
+}
+
+// Restores named.conf.local along with the files of the zones it declares or no longer declares.
+// Zones declared again get the newest version of their file back, unless the file is still there,
+// and the files of the zones no longer declared are removed, all in the same transaction.
+func (bs *BindService) restoreBindConf(content []byte) error {
+	bindConf, err := parser.ConfParser.ParseString(bs.ZonesFilePath, string(content))
+	if err != nil {
+		return err
+	}
+
+	// Zones added with rndc addzone are not in named.conf.local, so they are kept as they are
+	for _, zone := range bs.BindConf.Zones {
+		if zone.Added && bindConf.GetZone(zone.Name) == nil {
+			bindConf.Zones = append(bindConf.Zones, zone)
+		}
+	}
+
+	// The catalog zone is created again on load if it is no longer declared
+	restored := bs.changedZones(bindConf, bs.BindConf)
+	removed := bs.changedZones(bs.BindConf, bindConf)
+
+	if err := bs.checkConf(bindConf, bs.OptionsConf); err != nil {
+		return err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, zone := range restored {
+		if err := bs.restoreZoneFile(tx, zone); err != nil {
+			return err
+		}
+	}
+
+	removedFiles := []string{}
+	for _, zone := range removed {
+		filename, err := bs.resolvePath(zone.File)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Remove(filename); err != nil {
+			return err
+		}
+		removedFiles = append(removedFiles, filename)
+	}
+
+	if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+		return err
+	}
+
+	catalog, err := bs.writeCatalog(tx, func(catalog *parser.CatalogZone) {
+		for _, zone := range removed {
+			catalog.RemoveMember(zone.Name)
+		}
+		for _, zone := range restored {
+			catalog.SetMember(zone.Name, nil)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := bs.Reconfig(); err != nil {
+		return err
+	}
+
+	if err := bs.modifyAddedZones(bindConf); err != nil {
+		return err
+	}
+
+	if err := bs.reloadCatalog(catalog); err != nil {
+		return err
+	}
+
+	tx.Commit()
+
+	for _, filename := range removedFiles {
+		bs.removeJournal(filename)
+	}
+
+	// Zones may have been added or removed, so every file is loaded again
+	if err := bs.Load(); err != nil {
+		return fmt.Errorf("configuration restored, but it could not be loaded: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the primary zones declared in `bindConf` but not in `other`, except the catalog zone.
+func (bs *BindService) changedZones(bindConf, other *parser.BindConf) []*parser.Zone {
+	zones := []*parser.Zone{}
+	for _, zone := range bindConf.Zones {
+		if zone.IsPrimary() && zone.Name != bs.Setting.CatalogZone && other.GetZone(zone.Name) == nil {
+			zones = append(zones, zone)
+		}
+	}
+
+	return zones
+}
+
+// Writes the newest version of the file of a zone declared again by a restored configuration,
+// with a new serial. Does nothing if the file still exists.
+func (bs *BindService) restoreZoneFile(tx *file.Transaction, zone *parser.Zone) error {
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	versions, err := bs.History.List(filename)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		return fmt.Errorf("zone %s has no file and no version of it to restore", zone.Name)
+	}
+
+	content, err := bs.History.Read(filename, versions[0].Id)
+	if err != nil {
+		return err
+	}
+
+	zoneFile, err := bs.parseZoneFile(zone, filename, string(content))
+	if err != nil {
+		return err
+	}
+
+	zoneFile.UpdateSerial()
+
+	if err := bs.checkZone(zone.Name, filename, zoneFile); err != nil {
+		return err
+	}
+
+	return zoneFile.WriteToDisk(tx, filename)
+}
+
+// File of a zone or of a response policy zone, as written by the API.
+type zoneFile interface {
+	String() string
+	UpdateSerial()
+	WriteToDisk(tx *file.Transaction, filename string) error
+}
+
+// Parses the content of the file of a zone, which holds rules if it is a response policy zone.
+func (bs *BindService) parseZoneFile(zone *parser.Zone, filename, content string) (zoneFile, error) {
+	for _, name := range bs.OptionsConf.ResponsePolicyZones() {
+		if name == zone.Name {
+			return parser.ParseRPZZone(zone.Name, content)
+		}
+	}
+
+	zConf, err := parseZone(filename, content, zone.Name)
+	if err != nil {
+		return nil, err
+	}
+	zConf.File = zone.File
+
+	return zConf, nil
+}
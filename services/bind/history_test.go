@@ -0,0 +1,58 @@
+package bind_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestRestoreBindConf(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records:   []parser.Record{parser.NSRecord{Type: "NS", NameServer: "ns1"}},
+	}
+
+	bindService, _ := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.HistorySize = 0
+	})
+
+	_, err := bindService.CreateZone(&schemas.ZoneData{
+		Origin: "example.org", Ttl: "1d", NameServer: "ns1", Admin: "admin",
+		Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, bindService.AddRecord("example.org", parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.1"}))
+
+	versions, err := bindService.ListVersions("")
+	if !assert.Nil(t, err) || !assert.Len(t, versions, 2) {
+		return
+	}
+
+	// Restoring the configuration before the zone was created removes its file
+	assert.Nil(t, bindService.RestoreVersion("", versions[1].Id))
+	assert.NoFileExists(t, bindService.Setting.LibPath+"db.example.org")
+
+	_, err = bindService.GetZone("example.org")
+	assert.NotNil(t, err)
+
+	// Restoring the configuration with the zone brings back the newest version of its file
+	assert.Nil(t, bindService.RestoreVersion("", versions[0].Id))
+
+	restored, err := bindService.GetZone("example.org")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []parser.Record{parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.1"}}, restored.Records)
+	}
+
+	_, err = bindService.GetZone("example.com")
+	assert.Nil(t, err)
+}
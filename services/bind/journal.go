@@ -0,0 +1,123 @@
+package bind
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Edit of a zone file by the API. Dynamic zones are frozen while their file is edited, otherwise BIND
+// would keep applying updates to its journal, losing them when the file is reloaded, or refuse to load
+// a file that no longer matches the journal.
+type zoneEdit struct {
+	bs     *BindService
+	origin string
+	frozen bool
+	// Zone to apply the changes to
+	zConf *parser.ZoneConf
+	// Receives the files written by the edit once committed, instead of recording them in the git store
+	// with the other changes of the request. Set by edits made out of a request
+	onCommit func(files []string)
+}
+
+// Prepares the file of the zone to be edited. Dynamic zones are frozen, which writes their journal
+// to the file, and parsed again so the changes are applied over the data served by BIND.
+// Zones that receive the record changes as dynamic updates are not frozen, their file is never written.
+// The edit must be finished with `reload`, or with `thaw` when it is abandoned.
+func (bs *BindService) editZone(zConf *parser.ZoneConf) (*zoneEdit, error) {
+	edit := &zoneEdit{bs: bs, origin: zConf.Origin, zConf: zConf}
+
+	zone := bs.BindConf.GetZone(zConf.Origin)
+	if zone == nil || !zone.IsDynamic() || bs.updateClient(zConf.Origin) != nil {
+		return edit, nil
+	}
+
+	if _, err := bs.control("freeze", zConf.Origin); err != nil {
+		return nil, err
+	}
+	edit.frozen = true
+
+	current, err := bs.loadZoneFile(zone)
+	if err != nil {
+		edit.thaw()
+		return nil, fmt.Errorf("zone %s can not be edited, the file synced from its journal is invalid: %w", zone.Name, err)
+	}
+
+	bs.setZone(zConf.Origin, current)
+	edit.zConf = current
+
+	// The file was written by BIND and is now loaded
+	if filename, err := bs.resolvePath(zone.File); err == nil {
+		if err := bs.Tracker.Track(filename); err != nil {
+			log.Printf("Error tracking file %s: %s\n", filename, err)
+		}
+	}
+
+	return edit, nil
+}
+
+// Makes BIND load the edited zone file. Frozen zones are thawed, which also reloads them,
+// since BIND refuses to reload dynamic zones.
+func (e *zoneEdit) reload() error {
+	if !e.frozen {
+		return e.bs.ReloadZone(e.origin)
+	}
+
+	if _, err := e.bs.control("thaw", e.origin); err != nil {
+		return err
+	}
+	e.frozen = false
+
+	return nil
+}
+
+// Thaws the zone if it is still frozen, so BIND accepts dynamic updates again.
+func (e *zoneEdit) thaw() {
+	if !e.frozen {
+		return
+	}
+
+	if _, err := e.bs.control("thaw", e.origin); err != nil {
+		log.Printf("Error thawing zone %s: %s\n", e.origin, err)
+		return
+	}
+	e.frozen = false
+}
+
+// Parses the file of a zone.
+func (bs *BindService) loadZoneFile(zone *parser.Zone) (*parser.ZoneConf, error) {
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return nil, err
+	}
+
+	zConf, err := bs.parseZoneConf(filename, zone.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	zConf.File = zone.File
+
+	return zConf, nil
+}
+
+// Runs `rndc sync` for a dynamic zone, writing the changes held in its journal to the zone file
+// so the API does not load outdated records.
+func (bs *BindService) syncZone(zone *parser.Zone) error {
+	if !zone.IsDynamic() {
+		return nil
+	}
+
+	_, err := bs.control("sync", zone.Name)
+	return err
+}
+
+// Removes the journal left by a deleted dynamic zone, otherwise BIND would apply it to a new zone
+// with the same file.
+func (bs *BindService) removeJournal(filename string) {
+	if err := os.Remove(filename + ".jnl"); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing journal %s.jnl: %s\n", filename, err)
+	}
+}
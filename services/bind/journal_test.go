@@ -0,0 +1,44 @@
+package bind_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestDynamicZoneFile(t *testing.T) {
+	// Zone file written by BIND when it synced the journal of the zone
+	dump, err := os.ReadFile("parser/testdata/db.example.com.dynamic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindService, runner := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n\tallow-update { 127.0.0.1; };\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": string(dump),
+	}, nil)
+
+	zConf, err := bindService.GetZone("example.com")
+	if assert.Nil(t, err) {
+		assert.Len(t, zConf.Other, 4)
+	}
+
+	// The zone is edited frozen, and the records the API does not model are written back
+	assert.Nil(t, bindService.AddRecord("example.com", parser.ARecord{Type: "A", Name: "ftp", Ip: "10.0.0.5"}))
+	assert.Equal(t, []string{"rndc freeze example.com", "rndc thaw example.com"}, runner.Commands())
+
+	content, _ := os.ReadFile(bindService.Setting.LibPath + "db.example.com")
+	assert.Contains(t, string(content), "ftp IN A 10.0.0.5")
+	assert.Contains(t, string(content), "www.example.com.\t300\tIN\tAAAA\t2001:db8::2")
+	assert.Contains(t, string(content), "_sip._tcp.example.com.\t86400\tIN\tSRV\t0 5 5060 sip.example.com.")
+
+	// BIND writes the file again when it syncs the journal, which is not a conflict
+	if err := os.WriteFile(bindService.Setting.LibPath+"db.example.com", dump, 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, bindService.DeleteZone("example.com"))
+}
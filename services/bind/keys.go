@@ -0,0 +1,147 @@
+package bind
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Secret sizes in bytes for the supported TSIG algorithms, matching the output size of each hash.
+var keyAlgorithms = map[string]int{
+	"hmac-sha256": 32,
+	"hmac-sha512": 64,
+}
+
+func generateKey(name, algorithm string) (*parser.Key, error) {
+	size, ok := keyAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key algorithm %s", algorithm)
+	}
+
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return &parser.Key{
+		Name:      name,
+		Algorithm: algorithm,
+		Secret:    base64.StdEncoding.EncodeToString(secret),
+	}, nil
+}
+
+func (bs *BindService) ListKeys() []*schemas.KeyInfo {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	keys := []*schemas.KeyInfo{}
+
+	for _, key := range bs.BindConf.Keys {
+		keys = append(keys, &schemas.KeyInfo{
+			Name:       key.Name,
+			Algorithm:  key.Algorithm,
+			References: bs.BindConf.KeyReferences(key.Name, bs.OptionsConf),
+		})
+	}
+
+	return keys
+}
+
+func (bs *BindService) CreateKey(data *schemas.KeyData) (*parser.Key, error) {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	key, err := generateKey(data.Name, data.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	bindConf := *bs.BindConf
+
+	if err := bindConf.AddKey(key); err != nil {
+		return nil, err
+	}
+
+	if err := bs.applyBindConf(&bindConf); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Replaces the secret of a key with a new random one, keeping its name and algorithm.
+func (bs *BindService) RotateKey(name string) (*parser.Key, error) {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	oldKey := bs.BindConf.GetKey(name)
+	if oldKey == nil {
+		return nil, fmt.Errorf("key %s does not exist", name)
+	}
+
+	key, err := generateKey(oldKey.Name, oldKey.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	bindConf := *bs.BindConf
+
+	if err := bindConf.UpdateKey(key); err != nil {
+		return nil, err
+	}
+
+	if err := bs.applyBindConf(&bindConf); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (bs *BindService) DeleteKey(name string) error {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	bindConf := *bs.BindConf
+
+	if err := bindConf.DeleteKey(name, bs.OptionsConf); err != nil {
+		return err
+	}
+
+	return bs.applyBindConf(&bindConf)
+}
+
+// Writes the configuration to disk and reconfigures BIND, rolling back on error.
+// Changes of zones added with `rndc addzone` are applied with `rndc modzone`.
+// The caller must hold the mutex for writing.
+func (bs *BindService) applyBindConf(bindConf *parser.BindConf) error {
+	if err := bs.checkConf(bindConf, bs.OptionsConf); err != nil {
+		return err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+		return err
+	}
+
+	if err := bs.Reconfig(); err != nil {
+		return err
+	}
+
+	if err := bs.modifyAddedZones(bindConf); err != nil {
+		return err
+	}
+
+	tx.Commit()
+
+	bs.BindConf = bindConf
+
+	return nil
+}
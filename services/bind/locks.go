@@ -0,0 +1,154 @@
+package bind
+
+import (
+	"sync"
+
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Changes of the configuration, or of files shared by zones like the catalog, hold `Mutex` for writing.
+// Changes of a single zone hold it for reading along with the lock of the zone, so changes of other
+// zones, which may run slow commands, are not blocked meanwhile.
+//
+// The zones are published as read-only snapshots: the maps and the zones in them are never changed once
+// published, changes are applied to copies that replace them on success. Readers only need `state`
+// to get the current maps, and a failed change leaves nothing behind.
+
+// Locks the zone for a change of its file, returns the function that unlocks it.
+func (bs *BindService) lockZone(origin string) func() {
+	bs.Mutex.RLock()
+
+	bs.state.Lock()
+	lock, ok := bs.zoneLocks[origin]
+	if !ok {
+		lock = &sync.Mutex{}
+		bs.zoneLocks[origin] = lock
+	}
+	bs.state.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+		bs.Mutex.RUnlock()
+	}
+}
+
+// Returns the current snapshot of the zones.
+func (bs *BindService) zones() map[string]*parser.ZoneConf {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	return bs.Zones
+}
+
+func (bs *BindService) zone(origin string) (*parser.ZoneConf, bool) {
+	zConf, ok := bs.zones()[origin]
+	return zConf, ok
+}
+
+// Publishes the zones, replacing the current snapshot.
+func (bs *BindService) setZones(zones map[string]*parser.ZoneConf) {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	bs.Zones = zones
+}
+
+// Publishes a new snapshot with the zone replaced, or removed if zConf is nil.
+func (bs *BindService) setZone(origin string, zConf *parser.ZoneConf) {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	zones := make(map[string]*parser.ZoneConf, len(bs.Zones)+1)
+	for o, z := range bs.Zones {
+		zones[o] = z
+	}
+
+	if zConf != nil {
+		zones[origin] = zConf
+	} else {
+		delete(zones, origin)
+	}
+
+	bs.Zones = zones
+}
+
+// Returns the current snapshot of the response policy zones.
+func (bs *BindService) rpZones() map[string]*parser.RPZZone {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	return bs.RPZones
+}
+
+func (bs *BindService) setRPZones(rpZones map[string]*parser.RPZZone) {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	bs.RPZones = rpZones
+}
+
+// Publishes a new snapshot with the response policy zone replaced, or removed if rz is nil.
+func (bs *BindService) setRPZone(name string, rz *parser.RPZZone) {
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	rpZones := make(map[string]*parser.RPZZone, len(bs.RPZones)+1)
+	for n, z := range bs.RPZones {
+		rpZones[n] = z
+	}
+
+	if rz != nil {
+		rpZones[name] = rz
+	} else {
+		delete(rpZones, name)
+	}
+
+	bs.RPZones = rpZones
+}
+
+// Files changed through a view of the service.
+type changeSet struct {
+	mutex sync.Mutex
+	files []string
+}
+
+// Adds files to the ones changed since the last commit to the git store, the ones of the view
+// if the service is one.
+func (bs *BindService) addChanged(files []string) {
+	if bs.changes != nil {
+		bs.changes.mutex.Lock()
+		defer bs.changes.mutex.Unlock()
+
+		bs.changes.files = append(bs.changes.files, files...)
+		return
+	}
+
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	bs.changed = append(bs.changed, files...)
+}
+
+// Returns the files changed since the last commit to the git store, the ones of the view if the
+// service is one, and forgets them.
+func (bs *BindService) takeChanged() []string {
+	if bs.changes != nil {
+		bs.changes.mutex.Lock()
+		defer bs.changes.mutex.Unlock()
+
+		files := bs.changes.files
+		bs.changes.files = nil
+
+		return files
+	}
+
+	bs.state.Lock()
+	defer bs.state.Unlock()
+
+	files := bs.changed
+	bs.changed = nil
+
+	return files
+}
@@ -0,0 +1,119 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Files where BIND keeps the zones added with `rndc addzone` to the default view, as text or as
+// an LMDB database, depending on how it was built.
+const (
+	nzfName = "_default.nzf"
+	nzdName = "_default.nzd"
+)
+
+// Reads the zones added with `rndc addzone` and adds them to the loaded configuration.
+// An NZD database is converted to text with named-nzd2nzf, run where BIND is installed.
+func (bs *BindService) loadNewZones(bindConf *parser.BindConf, optionsConf *parser.StatementsFile) error {
+	if !bs.Setting.AllowNewZones {
+		return nil
+	}
+
+	if !optionsConf.AllowsNewZones() {
+		return errors.New("allow-new-zones must be enabled in the options to add zones with rndc addzone")
+	}
+
+	dir := optionsConf.GetOptions().Directory
+	if dir == "" {
+		return errors.New("the directory option is required to find the zones added with rndc addzone")
+	}
+
+	content, err := bs.readNewZones(dir)
+	if err != nil {
+		return err
+	}
+
+	added, err := parser.ConfParser.ParseString(path.Join(dir, nzfName), content)
+	if err != nil {
+		return err
+	}
+
+	zones := append([]*parser.Zone{}, bindConf.Zones...)
+	for _, zone := range added.Zones {
+		if bindConf.GetZone(zone.Name) != nil {
+			log.Printf("Zone %s added with rndc addzone is also in %s, ignoring the added one\n", zone.Name, bs.ZonesFilePath)
+			continue
+		}
+
+		zone.Added = true
+		zones = append(zones, zone)
+	}
+	fmt.Printf(">>> Loaded %d zone(s) added with rndc addzone\n", len(zones)-len(bindConf.Zones))
+
+	bindConf.Zones = zones
+
+	return nil
+}
+
+// Returns the zones added with `rndc addzone` in the format of the NZF file, converted from
+// the NZD database if BIND uses one. Empty if no zone was added yet. The files are looked up in the
+// directory of the options being loaded, as the loaded ones may not exist yet.
+func (bs *BindService) readNewZones(dir string) (string, error) {
+	nzf, err := bs.PathMap.Resolve(path.Join(dir, nzfName), dir)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(nzf)
+	if err == nil {
+		return string(content), nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	nzd, err := bs.PathMap.Resolve(path.Join(dir, nzdName), dir)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(nzd); os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return bs.exec("named-nzd2nzf", path.Join(dir, nzdName))
+}
+
+// Adds the zone to BIND, which keeps it in its NZF or NZD file.
+func (bs *BindService) addZone(zone *parser.Zone) error {
+	_, err := bs.control("addzone", zone.Name, zone.Config())
+	return err
+}
+
+// Deletes a zone added with `rndc addzone`, its files are left to the caller.
+func (bs *BindService) delZone(name string) error {
+	_, err := bs.control("delzone", name)
+	return err
+}
+
+// Applies the changes of the zones added with `rndc addzone`, which are not in named.conf.local,
+// with `rndc modzone`. Zones are compared with the current configuration, unchanged zones are kept as they are.
+func (bs *BindService) modifyAddedZones(bindConf *parser.BindConf) error {
+	for _, zone := range bindConf.Zones {
+		if !zone.Added || bs.BindConf.GetZone(zone.Name) == zone {
+			continue
+		}
+
+		if _, err := bs.control("modzone", zone.Name, zone.Config()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
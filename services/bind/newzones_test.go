@@ -0,0 +1,57 @@
+package bind_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestNewZones(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.org",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records: []parser.Record{
+			parser.NSRecord{Type: "NS", NameServer: "ns1"},
+			parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"},
+		},
+	}
+
+	zonesConf := "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n"
+
+	bindService, runner := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   zonesConf,
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n\tallow-new-zones yes;\n};\n",
+		"/var/cache/bind/_default.nzf": "zone \"example.org\" { type primary; file \"/var/lib/bind/db.example.org\"; };\n",
+		"/var/lib/bind/db.example.org": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.AllowNewZones = true
+	})
+
+	// Zones added with rndc addzone are loaded from the NZF file
+	_, err := bindService.GetZone("example.org")
+	assert.Nil(t, err)
+
+	_, err = bindService.CreateZone(&schemas.ZoneData{
+		Origin: "example.io", Ttl: "1d", NameServer: "ns1", Admin: "admin",
+		Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+	})
+	assert.Nil(t, err)
+	assert.FileExists(t, bindService.Setting.LibPath+"db.example.io")
+
+	assert.Nil(t, bindService.DeleteZone("example.org"))
+	assert.NoFileExists(t, bindService.Setting.LibPath+"db.example.org")
+
+	assert.Equal(t, []string{
+		`rndc addzone example.io { type master; file "/var/lib/bind/db.example.io"; };`,
+		"rndc delzone example.org",
+	}, runner.Commands())
+
+	content, _ := os.ReadFile(bindService.Setting.ConfPath + "named.conf.local")
+	assert.Equal(t, zonesConf, string(content))
+}
@@ -0,0 +1,102 @@
+package bind
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func (bs *BindService) parseOptionsConf(filename string) (*parser.StatementsFile, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return &parser.StatementsFile{Statements: parser.Statements{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	optionsConf, err := parser.StatementParser.Parse(filename, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return optionsConf, nil
+}
+
+func (bs *BindService) GetOptions() *parser.Options {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	return bs.OptionsConf.GetOptions()
+}
+
+// Applies the changes to the `options` block and reconfigures BIND.
+func (bs *BindService) UpdateOptions(data *schemas.OptionsData) (*parser.Options, error) {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	options := bs.OptionsConf.GetOptions()
+
+	if data.Directory != nil {
+		options.Directory = *data.Directory
+	}
+	if data.Recursion != nil {
+		options.Recursion = data.Recursion
+	}
+	if data.Forward != nil {
+		if *data.Forward != "" && *data.Forward != "first" && *data.Forward != "only" {
+			return nil, fmt.Errorf("invalid forward value %s, expected first or only", *data.Forward)
+		}
+		options.Forward = *data.Forward
+	}
+	if data.DnssecValidation != nil {
+		if *data.DnssecValidation != "" && *data.DnssecValidation != "auto" &&
+			*data.DnssecValidation != "yes" && *data.DnssecValidation != "no" {
+			return nil, fmt.Errorf("invalid dnssec-validation value %s, expected auto, yes or no", *data.DnssecValidation)
+		}
+		options.DnssecValidation = *data.DnssecValidation
+	}
+	if data.KeyDirectory != nil {
+		options.KeyDirectory = *data.KeyDirectory
+	}
+	if data.Forwarders != nil {
+		options.Forwarders = data.Forwarders
+	}
+	if data.AllowQuery != nil {
+		options.AllowQuery = data.AllowQuery
+	}
+	if data.AllowRecursion != nil {
+		options.AllowRecursion = data.AllowRecursion
+	}
+	if data.AllowTransfer != nil {
+		options.AllowTransfer = data.AllowTransfer
+	}
+
+	optionsConf := bs.OptionsConf.Copy()
+	optionsConf.SetOptions(options)
+
+	if err := bs.checkConf(bs.BindConf, optionsConf); err != nil {
+		return nil, err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := optionsConf.WriteToDisk(tx, bs.OptionsFilePath); err != nil {
+		return nil, err
+	}
+
+	if err := bs.Reconfig(); err != nil {
+		return nil, err
+	}
+
+	tx.Commit()
+
+	bs.OptionsConf = optionsConf
+
+	return optionsConf.GetOptions(), nil
+}
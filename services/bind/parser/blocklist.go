@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Host names found in the header of most hosts files, they are not blocked.
+var blocklistIgnored = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"ip6-localnet":          true,
+	"ip6-mcastprefix":       true,
+	"ip6-allnodes":          true,
+	"ip6-allrouters":        true,
+	"ip6-allhosts":          true,
+	"0.0.0.0":               true,
+}
+
+var blocklistDomain = regexp.MustCompile(`^([a-z0-9_]([a-z0-9_\-]{0,61}[a-z0-9_])?\.)*[a-z0-9_]([a-z0-9_\-]{0,61}[a-z0-9_])?$`)
+
+// Parses a blocklist in hosts format, like `0.0.0.0 ads.example`, or with one domain per line.
+// Domains are lowercased and deduplicated keeping the order of the list.
+// Returns the domains and the number of entries skipped because they are not valid domains.
+func ParseBlocklist(r io.Reader) ([]string, int, error) {
+	domains := []string{}
+	seen := map[string]bool{}
+	skipped := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#!"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Hosts entries map an address to one or more names
+		if net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+
+		for _, field := range fields {
+			domain := strings.TrimSuffix(strings.ToLower(field), ".")
+
+			if blocklistIgnored[domain] {
+				continue
+			}
+
+			if len(domain) > 253 || !blocklistDomain.MatchString(domain) {
+				skipped++
+				continue
+			}
+
+			if !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+
+	return domains, skipped, scanner.Err()
+}
+
+// Replaces every rule of the zone with a rule per domain.
+// Returns the number of domains that were not in the zone and of rules that are no longer present.
+func (rz *RPZZone) ReplaceRules(domains []string, action string, subdomains bool) (int, int) {
+	current := map[string]bool{}
+	for _, rule := range rz.Rules {
+		current[rule.Domain] = true
+	}
+
+	added := 0
+	rules := make([]*RPZRule, 0, len(domains))
+
+	for _, domain := range domains {
+		if current[domain] {
+			delete(current, domain)
+		} else {
+			added++
+		}
+		rules = append(rules, &RPZRule{Domain: domain, Action: action, Subdomains: subdomains})
+	}
+
+	rz.Rules = rules
+
+	return added, len(current)
+}
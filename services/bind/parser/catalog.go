@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+// Catalog zone as defined in RFC 9432. Secondaries configured with the catalog
+// learn from it which zones to serve, without touching their configuration.
+type CatalogZone struct {
+	Origin  string           `json:"origin"`
+	Serial  uint             `json:"serial"`
+	Members []*CatalogMember `json:"members"`
+}
+
+type CatalogMember struct {
+	// Unique label of the member in the catalog
+	Id     string   `json:"id"`
+	Zone   string   `json:"zone"`
+	Groups []string `json:"groups"`
+}
+
+// Builds a catalog member whose id is derived from the zone name, so it is stable across restarts.
+func NewCatalogMember(zone string, groups []string) *CatalogMember {
+	sum := sha1.Sum([]byte(strings.ToLower(strings.TrimSuffix(zone, "."))))
+
+	if groups == nil {
+		groups = []string{}
+	}
+
+	return &CatalogMember{Id: hex.EncodeToString(sum[:]), Zone: zone, Groups: groups}
+}
+
+// Parses a catalog zone file as written by CatalogZone.String.
+func ParseCatalogZone(origin, content string) (*CatalogZone, error) {
+	cz := &CatalogZone{Origin: origin, Members: []*CatalogMember{}}
+	groups := map[string][]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[1] != "IN" {
+			continue
+		}
+
+		owner, rType, value := fields[0], fields[2], fields[3]
+
+		switch {
+		case rType == "SOA":
+			if len(fields) < 7 {
+				return nil, fmt.Errorf("invalid SOA record in catalog zone %s", origin)
+			}
+			serial, err := strconv.ParseUint(fields[6], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			cz.Serial = uint(serial)
+		case rType == "PTR" && strings.HasSuffix(owner, ".zones"):
+			cz.Members = append(cz.Members, &CatalogMember{
+				Id:     strings.TrimSuffix(owner, ".zones"),
+				Zone:   strings.TrimSuffix(value, "."),
+				Groups: []string{},
+			})
+		case rType == "TXT" && strings.HasPrefix(owner, "group.") && strings.HasSuffix(owner, ".zones"):
+			id := strings.TrimSuffix(strings.TrimPrefix(owner, "group."), ".zones")
+			groups[id] = append(groups[id], strings.Trim(strings.Join(fields[3:], " "), `"`))
+		}
+	}
+
+	for _, member := range cz.Members {
+		if memberGroups, ok := groups[member.Id]; ok {
+			member.Groups = memberGroups
+		}
+	}
+
+	return cz, scanner.Err()
+}
+
+func (cz *CatalogZone) GetMember(zone string) *CatalogMember {
+	for _, member := range cz.Members {
+		if member.Zone == zone {
+			return member
+		}
+	}
+	return nil
+}
+
+// Adds a member to the catalog, or replaces its groups if it is a member already.
+// Members are replaced in a new slice so copies of the catalog are not affected.
+func (cz *CatalogZone) SetMember(zone string, groups []string) {
+	member := NewCatalogMember(zone, groups)
+	members := []*CatalogMember{}
+	found := false
+
+	for _, m := range cz.Members {
+		if m.Zone == zone {
+			member.Id = m.Id
+			members = append(members, member)
+			found = true
+		} else {
+			members = append(members, m)
+		}
+	}
+
+	if !found {
+		members = append(members, member)
+	}
+
+	cz.Members = members
+}
+
+func (cz *CatalogZone) RemoveMember(zone string) {
+	members := []*CatalogMember{}
+
+	for _, member := range cz.Members {
+		if member.Zone != zone {
+			members = append(members, member)
+		}
+	}
+
+	cz.Members = members
+}
+
+// Renders the catalog zone. The SOA and NS records are required by the zone format,
+// but they are never used since catalog zones are not meant to be queried.
+func (cz *CatalogZone) String() string {
+	content := []string{
+		fmt.Sprintf("$ORIGIN %s.", cz.Origin),
+		"$TTL 0",
+		fmt.Sprintf("@ IN SOA invalid. invalid. ( %d 3600 600 2147483646 0 )", cz.Serial),
+		"@ IN NS invalid.",
+		"version IN TXT \"2\"",
+	}
+
+	for _, member := range cz.Members {
+		content = append(content, fmt.Sprintf("%s.zones IN PTR %s.", member.Id, member.Zone))
+		for _, group := range member.Groups {
+			content = append(content, fmt.Sprintf("group.%s.zones IN TXT \"%s\"", member.Id, group))
+		}
+	}
+
+	return strings.Join(content, "\n") + "\n"
+}
+
+// Writes the catalog zone to a plain text file as it is, see ZoneConf.WriteToDisk.
+func (cz *CatalogZone) WriteToDisk(tx *file.Transaction, filename string) error {
+	return tx.Write(filename, []byte(cz.String()))
+}
+
+// Generates a new serial for the catalog zone.
+func (cz *CatalogZone) UpdateSerial() {
+	cz.Serial = nextSerial(cz.Serial)
+}
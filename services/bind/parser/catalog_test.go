@@ -0,0 +1,26 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestCatalogZone(t *testing.T) {
+	catalog := &parser.CatalogZone{Origin: "catalog.invalid", Serial: 1}
+
+	catalog.SetMember("example.com", []string{"public"})
+	catalog.SetMember("example.org", nil)
+	catalog.SetMember("example.net", []string{"internal", "signed"})
+	catalog.RemoveMember("example.org")
+
+	parsed, err := parser.ParseCatalogZone("catalog.invalid", catalog.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, catalog, parsed)
+	assert.Equal(t, parser.NewCatalogMember("example.net", nil).Id, parsed.Members[1].Id)
+	assert.Equal(t, []string{"internal", "signed"}, parsed.GetMember("example.net").Groups)
+}
@@ -1,50 +1,320 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
-	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
 	"github.com/svex99/bind-api/pkg/file"
 )
 
 var (
 	ConfLexer = lexer.MustSimple([]lexer.SimpleRule{
-		{Name: "Keyword", Pattern: `[a-zA-Z\-]+`},
+		{Name: "Address", Pattern: `[0-9a-fA-F]*:[0-9a-fA-F:\.]*(/\d+)?|\d+(\.\d+){3}(/\d+)?|\d+(\.\d+){0,2}/\d+`},
+		{Name: "Number", Pattern: `\d+[a-zA-Z%]*`},
+		{Name: "Keyword", Pattern: `[a-zA-Z][a-zA-Z0-9\-\.]*`},
 		{Name: "String", Pattern: `"[^"\n]*"`},
-		{Name: "Punct", Pattern: `[\{\}\;]`},
-		{Name: "Comment", Pattern: `//[^\n]*\n+`},
+		{Name: "Punct", Pattern: `[\{\}\;\!]`},
+		{Name: "Comment", Pattern: `(//|#)[^\n]*|/\*(?s:.)*?\*/`},
 		{Name: "Whitespace", Pattern: `[ \t\r\n]+`},
+		// Any other value, like the `*` wildcard
+		{Name: "Word", Pattern: `[^\s\{\}\;\!"]+`},
 	})
-	ConfParser = participle.MustBuild[BindConf](
-		participle.Lexer(ConfLexer),
-		participle.Unquote("String"),
-		participle.Elide("Whitespace", "Comment"),
-		participle.UseLookahead(1),
-	)
+	ConfParser = &confParser{}
 )
 
+var ErrKeyReferenced = errors.New("key is still referenced")
+
+type confParser struct{}
+
+// Parses a named.conf file. Zones, keys and acls are read into typed values and any other
+// statement is kept as it is, so writing the configuration back only changes what the API manages.
+func (cp *confParser) Parse(filename string, r io.Reader) (*BindConf, error) {
+	statementsFile, err := StatementParser.Parse(filename, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBindConf(statementsFile), nil
+}
+
+func (cp *confParser) ParseString(filename, content string) (*BindConf, error) {
+	statementsFile, err := StatementParser.ParseString(filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBindConf(statementsFile), nil
+}
+
+func (cp *confParser) String() string {
+	return StatementParser.String()
+}
+
 type BindConf struct {
-	Zones []*Zone `parser:"@@*"`
+	Acls  []*Acl
+	Keys  []*Key
+	Zones []*Zone
+
+	// parsed statements, including the ones unknown to the API
+	statementsFile *StatementsFile
 }
 
 type Zone struct {
-	Name string `parser:"'zone' @String '{'"`
-	Type string `parser:"'type' @('primary'|'master') ';'"`
-	File string `parser:"'file' @String ';' '}' ';'"`
+	Name          string
+	Type          string
+	File          string
+	AllowUpdate   []*AddressMatch
+	AllowQuery    []*AddressMatch
+	AllowTransfer []*AddressMatch
+	AlsoNotify    []string
+	DnssecPolicy  string
+	InlineSigning bool
+	KeyDirectory  string
+	// Added with `rndc addzone`, BIND keeps the zone in its NZF or NZD file instead of named.conf.local
+	Added bool
+
+	statement *Statement
+}
+
+// TSIG key shared with BIND, used to authenticate updates and zone transfers.
+type Key struct {
+	Name      string `json:"name"`
+	Algorithm string `json:"algorithm"`
+	Secret    string `json:"-"`
+
+	statement *Statement
+}
+
+type Acl struct {
+	Name     string
+	Elements []*AddressMatch
+
+	statement *Statement
+}
+
+// Element of an address match list, either a TSIG key reference or a plain value
+// (address, prefix, acl name or nested list).
+type AddressMatch struct {
+	Negated bool
+	Key     string
+	Value   string
+}
+
+func newBindConf(statementsFile *StatementsFile) *BindConf {
+	bc := &BindConf{
+		Acls:           []*Acl{},
+		Keys:           []*Key{},
+		Zones:          []*Zone{},
+		statementsFile: statementsFile,
+	}
+
+	for _, statement := range statementsFile.Statements {
+		switch statement.Name() {
+		case "acl":
+			bc.Acls = append(bc.Acls, aclFromStatement(statement))
+		case "key":
+			bc.Keys = append(bc.Keys, keyFromStatement(statement))
+		case "zone":
+			bc.Zones = append(bc.Zones, zoneFromStatement(statement))
+		}
+	}
+
+	return bc
+}
+
+func statementName(statement *Statement) string {
+	if values := statement.Values(); len(values) > 0 {
+		return Unquote(values[0])
+	}
+	return ""
+}
+
+func statementBlock(statement *Statement) Statements {
+	if block := statement.Block(); block != nil {
+		return block.Statements
+	}
+	return Statements{}
+}
+
+func zoneFromStatement(statement *Statement) *Zone {
+	block := statementBlock(statement)
+
+	return &Zone{
+		Name:          statementName(statement),
+		Type:          getValue(block, "type"),
+		File:          Unquote(getValue(block, "file")),
+		AllowUpdate:   addressMatchList(block.Find("allow-update")),
+		AllowQuery:    addressMatchList(block.Find("allow-query")),
+		AllowTransfer: addressMatchList(block.Find("allow-transfer")),
+		AlsoNotify:    getList(block, "also-notify"),
+		DnssecPolicy:  Unquote(getValue(block, "dnssec-policy")),
+		InlineSigning: getValue(block, "inline-signing") == "yes",
+		KeyDirectory:  Unquote(getValue(block, "key-directory")),
+		statement:     statement,
+	}
+}
+
+func keyFromStatement(statement *Statement) *Key {
+	block := statementBlock(statement)
+
+	return &Key{
+		Name:      statementName(statement),
+		Algorithm: Unquote(getValue(block, "algorithm")),
+		Secret:    Unquote(getValue(block, "secret")),
+		statement: statement,
+	}
+}
+
+func aclFromStatement(statement *Statement) *Acl {
+	return &Acl{
+		Name:      statementName(statement),
+		Elements:  addressMatchList(statement),
+		statement: statement,
+	}
+}
+
+func addressMatchList(statement *Statement) []*AddressMatch {
+	if statement == nil || statement.Block() == nil {
+		return nil
+	}
+
+	elements := []*AddressMatch{}
+
+	for _, element := range statement.Block().Statements {
+		am := &AddressMatch{}
+		args := element.Args
+
+		if len(args) > 0 && args[0].Value == "!" {
+			am.Negated = true
+			args = args[1:]
+		}
+
+		if len(args) == 2 && args[0].Value == "key" {
+			am.Key = Unquote(args[1].Value)
+		} else {
+			am.Value = (&Statement{Args: args}).Inline()
+		}
+
+		elements = append(elements, am)
+	}
+
+	return elements
+}
+
+// Returns true if the zone is a primary zone, the only kind of zone managed by the API.
+func (z *Zone) IsPrimary() bool {
+	return z.Type == "primary" || z.Type == "master"
+}
+
+// Returns true if the zone accepts dynamic updates, so BIND keeps its changes in a `.jnl` journal
+// that is written to the zone file only from time to time.
+func (z *Zone) IsDynamic() bool {
+	for _, match := range z.AllowUpdate {
+		if !match.Negated && match.Value != "none" {
+			return true
+		}
+	}
+
+	return z.statement != nil && statementBlock(z.statement).Find("update-policy") != nil
+}
+
+// Builds the zone statement, updating the parsed one if any so unknown zone options are preserved.
+func (z *Zone) Statement() *Statement {
+	var statement *Statement
+
+	if z.statement != nil {
+		statement = z.statement.copy()
+	} else {
+		statement = NewStatement("zone", Quote(z.Name))
+		statement.Args = append(statement.Args, &Arg{Block: &Block{Statements: Statements{}}})
+	}
+
+	block := &statement.Block().Statements
+
+	setValue(block, "type", z.Type)
+	setString(block, "file", z.File)
+	setList(block, "allow-update", addressMatchStrings(z.AllowUpdate))
+	setList(block, "allow-query", addressMatchStrings(z.AllowQuery))
+	setList(block, "allow-transfer", addressMatchStrings(z.AllowTransfer))
+	setList(block, "also-notify", z.AlsoNotify)
+	setString(block, "dnssec-policy", z.DnssecPolicy)
+	if z.InlineSigning {
+		setValue(block, "inline-signing", "yes")
+	} else {
+		setValue(block, "inline-signing", "")
+	}
+	setString(block, "key-directory", z.KeyDirectory)
+
+	return statement
+}
+
+// Returns the options of the zone in a single line, as taken by `rndc addzone` and `rndc modzone`.
+func (z *Zone) Config() string {
+	return z.Statement().Block().inline() + ";"
+}
+
+func (k *Key) Statement() *Statement {
+	var statement *Statement
+
+	if k.statement != nil {
+		statement = k.statement.copy()
+	} else {
+		statement = NewStatement("key", Quote(k.Name))
+		statement.Args = append(statement.Args, &Arg{Block: &Block{Statements: Statements{}}})
+	}
+
+	block := &statement.Block().Statements
+
+	setValue(block, "algorithm", k.Algorithm)
+	setString(block, "secret", k.Secret)
+
+	return statement
+}
+
+func (a *Acl) Statement() *Statement {
+	var statement *Statement
+
+	if a.statement != nil {
+		statement = a.statement.copy()
+	} else {
+		statement = NewStatement("acl", Quote(a.Name))
+		statement.Args = append(statement.Args, &Arg{Block: &Block{Statements: Statements{}}})
+	}
+
+	elements := NewListStatement("", addressMatchStrings(a.Elements)).Block()
+	if statement.Block().inline() != elements.inline() {
+		*statement.Block() = *elements
+	}
+
+	return statement
 }
 
-func (bc *BindConf) WriteToDisk(filename string) (func(), error) {
-	// Create a backup of config if file exists
-	rollback := file.MakeBackup(filename)
+func (am *AddressMatch) String() string {
+	negation := ""
+	if am.Negated {
+		negation = "!"
+	}
+
+	if am.Key != "" {
+		return fmt.Sprintf("%skey %s", negation, Quote(am.Key))
+	}
+
+	return negation + am.Value
+}
 
-	if err := os.WriteFile(filename, []byte(bc.String()), 0666); err != nil {
-		return rollback, err
+func addressMatchStrings(elements []*AddressMatch) []string {
+	list := []string{}
+	for _, element := range elements {
+		list = append(list, element.String())
 	}
+	return list
+}
 
-	return rollback, nil
+func (bc *BindConf) WriteToDisk(tx *file.Transaction, filename string) error {
+	return tx.Write(filename, []byte(bc.String()))
 }
 
 func (bc *BindConf) AddZone(dc *ZoneConf) error {
@@ -54,41 +324,272 @@ func (bc *BindConf) AddZone(dc *ZoneConf) error {
 		}
 	}
 
-	bc.Zones = append(bc.Zones, &Zone{dc.Origin, "master", "/var/lib/bind/db." + dc.Origin})
+	bc.Zones = append(append([]*Zone{}, bc.Zones...), &Zone{Name: dc.Origin, Type: "master", File: dc.File})
 
 	return nil
 }
 
+// Zones are removed in a new slice so copies of the configuration are not affected.
 func (bc *BindConf) DeleteZone(dc *ZoneConf) error {
-	foundIndex := -1
+	zones := []*Zone{}
+	found := false
 
-	for i, zone := range bc.Zones {
+	for _, zone := range bc.Zones {
 		if zone.Name == dc.Origin {
-			foundIndex = i
-			break
+			found = true
+		} else {
+			zones = append(zones, zone)
 		}
 	}
 
-	if foundIndex == -1 {
+	if !found {
 		return fmt.Errorf("zone does not exist")
 	}
 
-	bc.Zones = append(bc.Zones[:foundIndex], bc.Zones[foundIndex+1:]...)
+	bc.Zones = zones
 
 	return nil
 }
 
-func (bc *BindConf) String() string {
-	zones := []string{}
+func (bc *BindConf) GetZone(name string) *Zone {
+	for _, zone := range bc.Zones {
+		if zone.Name == name {
+			return zone
+		}
+	}
+
+	return nil
+}
+
+// Replaces the zone with the same name as `zone`, keeping its place in the configuration.
+// The zone is replaced in a new slice so copies of the configuration are not affected.
+func (bc *BindConf) UpdateZone(zone *Zone) error {
+	zones := make([]*Zone, len(bc.Zones))
+	found := false
+
+	for i, z := range bc.Zones {
+		if z.Name == zone.Name {
+			zone.statement = z.statement
+			zones[i] = zone
+			found = true
+		} else {
+			zones[i] = z
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("zone %s does not exist", zone.Name)
+	}
+
+	bc.Zones = zones
+
+	return nil
+}
+
+// Returns the names of the DNSSEC policies defined in the configuration.
+func (bc *BindConf) DnssecPolicies() []string {
+	if bc.statementsFile == nil {
+		return []string{}
+	}
+	return bc.statementsFile.DnssecPolicies()
+}
+
+func (bc *BindConf) GetKey(name string) *Key {
+	for _, key := range bc.Keys {
+		if key.Name == name {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func (bc *BindConf) AddKey(key *Key) error {
+	if bc.GetKey(key.Name) != nil {
+		return fmt.Errorf("key %s exists already", key.Name)
+	}
+
+	bc.Keys = append(append([]*Key{}, bc.Keys...), key)
+
+	return nil
+}
+
+// Replaces the key with the same name as `key`, keeping its place in the configuration.
+// The key is replaced in a new slice so copies of the configuration are not affected.
+func (bc *BindConf) UpdateKey(key *Key) error {
+	keys := make([]*Key, len(bc.Keys))
+	found := false
+
+	for i, k := range bc.Keys {
+		if k.Name == key.Name {
+			key.statement = k.statement
+			keys[i] = key
+			found = true
+		} else {
+			keys[i] = k
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("key %s does not exist", key.Name)
+	}
+
+	bc.Keys = keys
+
+	return nil
+}
+
+// Deletes the key with the given name.
+// Fails if the key is still referenced by the configuration or by the options.
+func (bc *BindConf) DeleteKey(name string, optionsConf *StatementsFile) error {
+	if bc.GetKey(name) == nil {
+		return fmt.Errorf("key %s does not exist", name)
+	}
+
+	if refs := bc.KeyReferences(name, optionsConf); len(refs) > 0 {
+		return fmt.Errorf("%w by %s", ErrKeyReferenced, strings.Join(refs, ", "))
+	}
+
+	keys := []*Key{}
+	for _, key := range bc.Keys {
+		if key.Name != name {
+			keys = append(keys, key)
+		}
+	}
+
+	bc.Keys = keys
+
+	return nil
+}
+
+// Returns the statements of the configuration, and of the options if given, that reference the key
+// with the given name, like `zone example.com` or `options`.
+func (bc *BindConf) KeyReferences(name string, optionsConf *StatementsFile) []string {
+	statements := bc.statements().Statements
 	for _, zone := range bc.Zones {
-		zones = append(zones, zone.String())
+		if zone.Added {
+			statements = append(statements, zone.Statement())
+		}
+	}
+	if optionsConf != nil {
+		statements = append(statements, optionsConf.Statements...)
+	}
+
+	refs := []string{}
+	for _, statement := range statements {
+		if referencesKey(statement, name) {
+			refs = append(refs, strings.TrimSpace(statement.Name()+" "+statementName(statement)))
+		}
+	}
+
+	return refs
+}
+
+// Returns true if the statement or any statement nested in its blocks references the key, either as
+// `key <name>` in address match lists and server lists like also-notify or primaries, as the identity
+// of `grant` and `deny` rules of update-policy, or in the `keys` of server and controls statements.
+func referencesKey(statement *Statement, name string) bool {
+	sameKey := func(value string) bool {
+		return strings.TrimSuffix(Unquote(value), ".") == strings.TrimSuffix(name, ".")
+	}
+
+	args := statement.Args
+
+	switch statement.Name() {
+	case "key":
+		// The definition of a key is not a reference to it
+		if statement.Block() != nil {
+			return false
+		}
+	case "grant", "deny":
+		if len(args) > 1 && sameKey(args[1].Value) {
+			return true
+		}
+	case "keys":
+		for _, value := range append(statement.Values(), statement.List()...) {
+			if sameKey(value) {
+				return true
+			}
+		}
+	}
+
+	for i, arg := range args {
+		if arg.Block != nil {
+			for _, nested := range arg.Block.Statements {
+				if referencesKey(nested, name) {
+					return true
+				}
+			}
+		} else if arg.Value == "key" && i+1 < len(args) && args[i+1].Block == nil && sameKey(args[i+1].Value) {
+			return true
+		}
 	}
-	return strings.Join(zones, "\n")
+
+	return false
+}
+
+// Renders the configuration. Statements unknown to the API and unchanged zones, keys and acls are
+// written as they were parsed. New acls and keys are placed before the first zone and new zones at the end.
+func (bc *BindConf) String() string {
+	return bc.statements().String()
 }
 
-func (z *Zone) String() string {
-	return fmt.Sprintf(
-		"zone \"%s\" {\n\ttype %s;\n\tfile \"%s\";\n};\n",
-		z.Name, z.Type, z.File,
-	)
+// Builds the statements of the configuration, with the acls, keys and zones as they are in memory.
+func (bc *BindConf) statements() *StatementsFile {
+	statementsFile := &StatementsFile{Statements: Statements{}}
+	if bc.statementsFile != nil {
+		statementsFile.trailing = bc.statementsFile.trailing
+	}
+
+	kept := map[*Statement]*Statement{}
+	definitions := Statements{}
+
+	for _, acl := range bc.Acls {
+		if acl.statement != nil {
+			kept[acl.statement] = acl.Statement()
+		} else {
+			definitions = append(definitions, acl.Statement())
+		}
+	}
+	for _, key := range bc.Keys {
+		if key.statement != nil {
+			kept[key.statement] = key.Statement()
+		} else {
+			definitions = append(definitions, key.Statement())
+		}
+	}
+	for _, zone := range bc.Zones {
+		if zone.statement != nil && !zone.Added {
+			kept[zone.statement] = zone.Statement()
+		}
+	}
+
+	if bc.statementsFile != nil {
+		for _, statement := range bc.statementsFile.Statements {
+			if statement.Name() == "zone" && len(definitions) > 0 {
+				statementsFile.Statements = append(statementsFile.Statements, definitions...)
+				definitions = nil
+			}
+
+			switch statement.Name() {
+			case "acl", "key", "zone":
+				// statements deleted from the typed lists are not rendered
+				if updated, ok := kept[statement]; ok {
+					statementsFile.Statements = append(statementsFile.Statements, updated)
+				}
+			default:
+				statementsFile.Statements = append(statementsFile.Statements, statement)
+			}
+		}
+	}
+
+	statementsFile.Statements = append(statementsFile.Statements, definitions...)
+
+	for _, zone := range bc.Zones {
+		if zone.statement == nil && !zone.Added {
+			statementsFile.Statements = append(statementsFile.Statements, zone.Statement())
+		}
+	}
+
+	return statementsFile
 }
@@ -2,6 +2,7 @@ package parser_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,216 @@ func TestParser(t *testing.T) {
 	t.Log(conf.String())
 	assert.Equal(t, conf.String(), string(content))
 }
+
+func TestKeyReferences(t *testing.T) {
+	content := `acl "trusted" { 10.0.0.0/8; !key "transfer"; };
+key "update" {
+	algorithm hmac-sha256;
+	secret "c2VjcmV0";
+};
+server 10.0.0.3 {
+	keys { "server"; };
+};
+zone "example.com" {
+	type master;
+	file "/var/lib/bind/db.example.com";
+	allow-update { key "update"; };
+	also-notify { 10.0.0.2 key "notify"; };
+};
+zone "example.org" {
+	type master;
+	file "/var/lib/bind/db.example.org";
+	update-policy { grant "policy" zonesub ANY; };
+	allow-query { key "query"; };
+};
+zone "example.net" {
+	type slave;
+	file "/var/cache/bind/db.example.net";
+	primaries { 10.0.0.1 key primary; };
+};
+`
+
+	conf, err := parser.ConfParser.ParseString("", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options, err := parser.StatementParser.ParseString("", `options {
+	directory "/var/cache/bind";
+	allow-transfer { key "options"; };
+};
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, conf.Keys, 1)
+	assert.Equal(t, "hmac-sha256", conf.Keys[0].Algorithm)
+	assert.Equal(t, []string{"zone example.com"}, conf.KeyReferences("update", options))
+	assert.Equal(t, []string{"acl trusted"}, conf.KeyReferences("transfer", options))
+	assert.Equal(t, []string{"zone example.com"}, conf.KeyReferences("notify", options))
+	assert.Equal(t, []string{"zone example.org"}, conf.KeyReferences("policy", options))
+	assert.Equal(t, []string{"zone example.org"}, conf.KeyReferences("query", options))
+	assert.Equal(t, []string{"zone example.net"}, conf.KeyReferences("primary", options))
+	assert.Equal(t, []string{"server 10.0.0.3"}, conf.KeyReferences("server", options))
+	assert.Equal(t, []string{"options"}, conf.KeyReferences("options", options))
+	assert.Empty(t, conf.KeyReferences("other", options))
+
+	// References of zones changed in memory are found too
+	zone := conf.GetZone("example.org")
+	zone.AllowTransfer = []*parser.AddressMatch{{Key: "changed"}}
+	assert.Nil(t, conf.UpdateZone(zone))
+	assert.Equal(t, []string{"zone example.org"}, conf.KeyReferences("changed", nil))
+
+	assert.ErrorIs(t, conf.DeleteKey("update", options), parser.ErrKeyReferenced)
+
+	conf.Keys = append(conf.Keys, &parser.Key{Name: "options", Algorithm: "hmac-sha256"})
+	assert.ErrorIs(t, conf.DeleteKey("options", options), parser.ErrKeyReferenced)
+	assert.Nil(t, conf.DeleteKey("options", nil))
+}
+
+func TestLosslessEdit(t *testing.T) {
+	content, err := os.ReadFile("testdata/named.conf.custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := parser.ConfParser.ParseString("", string(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, string(content), conf.String())
+
+	assert.Len(t, conf.Zones, 3)
+	assert.False(t, conf.Zones[1].IsPrimary())
+	assert.False(t, conf.Zones[0].IsDynamic())
+	assert.True(t, conf.Zones[2].IsDynamic())
+
+	// Delete the first zone and add a new one, the rest of the file must be unchanged
+	if err := conf.DeleteZone(&parser.ZoneConf{Origin: "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.AddZone(&parser.ZoneConf{Origin: "example.io", File: "/var/lib/bind/db.example.io"}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := strings.Index(string(content), "\n\nzone \"example.com\"")
+	end := strings.Index(string(content), "\n\n# secondary zone")
+	expected := string(content[:start]) + string(content[end:]) +
+		"\nzone \"example.io\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.io\";\n};\n"
+
+	assert.Equal(t, expected, conf.String())
+}
+
+func TestZoneDnssec(t *testing.T) {
+	content := `dnssec-policy "standard" {
+	keys { ksk lifetime unlimited algorithm ecdsap256sha256; };
+};
+
+zone "example.com" {
+	type master;
+	file "/var/lib/bind/db.example.com";
+};
+`
+
+	conf, err := parser.ConfParser.ParseString("", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"standard"}, conf.DnssecPolicies())
+
+	zone := *conf.GetZone("example.com")
+	zone.DnssecPolicy = "standard"
+	zone.InlineSigning = true
+
+	if err := conf.UpdateZone(&zone); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(
+		content, "db.example.com\";\n", "db.example.com\";\n\tdnssec-policy \"standard\";\n\tinline-signing yes;\n", 1,
+	)
+
+	assert.Equal(t, expected, conf.String())
+}
+
+func TestZoneDynamic(t *testing.T) {
+	content := `zone "example.com" {
+	type master;
+	file "/var/lib/bind/db.example.com";
+	allow-update { none; };
+};
+
+zone "example.org" {
+	type master;
+	file "/var/lib/bind/db.example.org";
+	update-policy local;
+};
+`
+
+	conf, err := parser.ConfParser.ParseString("", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, conf.GetZone("example.com").IsDynamic())
+	assert.True(t, conf.GetZone("example.org").IsDynamic())
+}
+
+func TestAddedZones(t *testing.T) {
+	content := `zone "example.com" {
+	type master;
+	file "/var/lib/bind/db.example.com";
+};
+`
+
+	// NZF files written by BIND, or converted from NZD by named-nzd2nzf
+	nzf := `# New zone file for view: _default
+# This file contains configuration for zones added by
+# the 'rndc addzone' command. DO NOT EDIT BY HAND.
+zone "example.org" { type primary; file "/var/lib/bind/db.example.org"; allow-update { key "update"; }; };
+zone example.net { type primary; file "/var/lib/bind/db.example.net"; };
+`
+
+	conf, err := parser.ConfParser.ParseString("", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := parser.ConfParser.ParseString("", nzf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, added.Zones, 2)
+	assert.Equal(t, "example.net", added.Zones[1].Name)
+
+	for _, zone := range added.Zones {
+		zone.Added = true
+		conf.Zones = append(conf.Zones, zone)
+	}
+
+	if err := conf.AddZone(&parser.ZoneConf{Origin: "example.io", File: "/var/lib/bind/db.example.io"}); err != nil {
+		t.Fatal(err)
+	}
+	conf.GetZone("example.io").Added = true
+
+	// Added zones are kept by BIND, not in named.conf.local
+	assert.Equal(t, content, conf.String())
+	assert.True(t, conf.GetZone("example.org").IsDynamic())
+
+	assert.Equal(t, `{ type master; file "/var/lib/bind/db.example.io"; };`, conf.GetZone("example.io").Config())
+	assert.Equal(
+		t, `{ type primary; file "/var/lib/bind/db.example.org"; allow-update { key "update"; }; };`,
+		conf.GetZone("example.org").Config(),
+	)
+
+	options, err := parser.StatementParser.ParseString("", "options {\n\tallow-new-zones yes;\n};\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, options.AllowsNewZones())
+	assert.False(t, (&parser.StatementsFile{}).AllowsNewZones())
+}
@@ -2,48 +2,35 @@ package parser
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/svex99/bind-api/pkg/file"
 )
 
-// Writes the zone configuration to a plain text file.
-// Returns a function that rollbacks the process.
-func (zc *ZoneConf) WriteToDisk(filename string) (func(), error) {
-	zc.UpdateSerial()
+// Writes the zone configuration to a plain text file as it is, so the serial must be updated
+// before the content is checked.
+func (zc *ZoneConf) WriteToDisk(tx *file.Transaction, filename string) error {
+	return tx.Write(filename, []byte(zc.String()))
+}
 
+// Renders the zone file.
+func (zc *ZoneConf) String() string {
 	content := []string{
 		fmt.Sprintf("$ORIGIN %s.", zc.Origin),
 		fmt.Sprintf("$TTL %s", zc.Ttl),
-		fmt.Sprintf(
-			"@ IN SOA %s %s ( %d %d %d %d %d )\n",
-			zc.SOARecord.NameServer, zc.SOARecord.Admin,
-			zc.SOARecord.Serial, zc.SOARecord.Refresh, zc.SOARecord.Retry, zc.SOARecord.Expire, zc.SOARecord.Minimum,
-		),
+		zc.SOARecord.String(),
 	}
 	for _, record := range zc.Records {
 		content = append(content, record.String())
 	}
+	content = append(content, zc.Other...)
 
-	// Create a backup of config if file exists
-	rollback := file.MakeBackup(filename)
-
-	if err := os.WriteFile(filename, []byte(strings.Join(content, "\n")), 0666); err != nil {
-		return rollback, err
-	}
-
-	return rollback, nil
+	return strings.Join(content, "\n")
 }
 
-func (zc *ZoneConf) DeleteFromDisk(filename string) (func(), error) {
-	// Create a backup of config if file exists
-	rollback := file.MakeBackup(filename)
-
-	// File is already deleted when made the backup, since it's renamed.
-
-	return rollback, nil
+func (zc *ZoneConf) DeleteFromDisk(tx *file.Transaction, filename string) error {
+	return tx.Remove(filename)
 }
 
 func (zc *ZoneConf) GetRecordIndex(targetRecord Record) int {
@@ -64,23 +51,30 @@ func (zc *ZoneConf) GetRecordStringIndex(target string) int {
 // Generates a new serial for the SOA record.
 // Generated serials follows the format YYYYMMDDNN where NN is a two digits identifier.
 func (zc *ZoneConf) UpdateSerial() {
+	soa := *zc.SOARecord
+	soa.Serial = nextSerial(soa.Serial)
+	zc.SOARecord = &soa
+}
+
+func nextSerial(serial uint) uint {
 	now := time.Now().UTC()
 	newSerial := uint(now.Year()*1_000_000 + int(now.Month())*10_000 + now.Day()*100)
 
-	if zc.SOARecord.Serial >= newSerial {
-		zc.SOARecord.Serial = zc.SOARecord.Serial + 1
-	} else {
-		zc.SOARecord.Serial = newSerial
+	if serial >= newSerial {
+		return serial + 1
 	}
+
+	return newSerial
 }
 
+// Records are changed in a new slice so copies of the zone are not affected.
 func (zc *ZoneConf) AddRecord(record Record) error {
 	index := zc.GetRecordIndex(record)
 	if index != -1 {
 		return fmt.Errorf("record '%s' exists already", record.String())
 	}
 
-	zc.Records = append(zc.Records, record)
+	zc.Records = append(append([]Record{}, zc.Records...), record)
 
 	return nil
 }
@@ -91,12 +85,9 @@ func (zc *ZoneConf) UpdateRecord(target string, record Record) error {
 		return fmt.Errorf("target '%s' record does not exist", target)
 	}
 
-	zc.Records[index] = record
-
-	if rollback, err := zc.WriteToDisk(zc.GetFilename()); err != nil {
-		rollback()
-		return err
-	}
+	records := append([]Record{}, zc.Records...)
+	records[index] = record
+	zc.Records = records
 
 	return nil
 }
@@ -107,7 +98,8 @@ func (zc *ZoneConf) DeleteRecord(record Record) error {
 		return fmt.Errorf("record '%s' does not exist", record.String())
 	}
 
-	zc.Records = append(zc.Records[:index], zc.Records[index+1:]...)
+	records := append([]Record{}, zc.Records[:index]...)
+	zc.Records = append(records, zc.Records[index+1:]...)
 
 	return nil
 }
@@ -54,3 +54,46 @@ package parser_test
 // 	t.Log(nsRec.GetOffsetAndLen())
 // 	t.Log(parsedConf)
 // }
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestZoneCopies(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1},
+		Records:   make([]parser.Record, 0, 8),
+	}
+	zConf.Records = append(zConf.Records,
+		parser.NSRecord{Type: "NS", NameServer: "ns1"},
+		parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"},
+		parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.2"},
+	)
+	original := zConf.String()
+
+	// Changes to a copy leave the zone it was copied from untouched
+	changed := *zConf
+	assert.Nil(t, changed.DeleteRecord(parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"}))
+	assert.Nil(t, changed.UpdateRecord("www IN A 10.0.0.2\n", parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.3"}))
+	assert.Nil(t, changed.AddRecord(parser.ARecord{Type: "A", Name: "mail", Ip: "10.0.0.4"}))
+	changed.UpdateSerial()
+
+	assert.Equal(t, original, zConf.String())
+	assert.Equal(t, uint(1), zConf.SOARecord.Serial)
+	assert.Len(t, changed.Records, 3)
+
+	other := *zConf
+	assert.Nil(t, other.AddRecord(parser.ARecord{Type: "A", Name: "ftp", Ip: "10.0.0.5"}))
+	assert.Equal(t, parser.ARecord{Type: "A", Name: "mail", Ip: "10.0.0.4"}, changed.Records[2])
+
+	bindConf := &parser.BindConf{Zones: []*parser.Zone{{Name: "example.com"}, {Name: "example.org"}}}
+	copied := *bindConf
+	assert.Nil(t, copied.DeleteZone(&parser.ZoneConf{Origin: "example.com"}))
+	assert.Equal(t, "example.com", bindConf.Zones[0].Name)
+	assert.Len(t, bindConf.Zones, 2)
+}
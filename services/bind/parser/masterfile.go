@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Parses a zone file in any format accepted by BIND, like the files written by BIND itself when it syncs
+// the journal of a dynamic zone, with `$ORIGIN .`, full names and a SOA record over several lines.
+// The origin of the zone must be given, since those files do not declare it.
+func ParseMasterFile(filename string, r io.Reader, origin string) (*ZoneConf, error) {
+	zoneParser := dns.NewZoneParser(r, dns.Fqdn(origin), filename)
+
+	rrs := []dns.RR{}
+	for rr, ok := zoneParser.Next(); ok; rr, ok = zoneParser.Next() {
+		rrs = append(rrs, rr)
+	}
+
+	if err := zoneParser.Err(); err != nil {
+		return nil, err
+	}
+
+	return ZoneFromRRs(origin, rrs)
+}
+
+// Builds the zone from its records, like the ones of a zone file or a transfer. The TTL of the SOA record is
+// taken as the default TTL of the zone. Records the API does not model, or with a TTL of their own, are kept
+// in `Other` as they are.
+func ZoneFromRRs(origin string, rrs []dns.RR) (*ZoneConf, error) {
+	fqdn := dns.Fqdn(strings.ToLower(origin))
+
+	relative := func(name string) string {
+		name = strings.ToLower(name)
+		if name == fqdn {
+			return "@"
+		}
+		if strings.HasSuffix(name, "."+fqdn) {
+			return strings.TrimSuffix(name, "."+fqdn)
+		}
+		return name
+	}
+
+	zConf := &ZoneConf{
+		Origin:  strings.TrimSuffix(origin, "."),
+		Records: []Record{},
+		Other:   []string{},
+	}
+
+	var ttl uint32
+	for _, rr := range rrs {
+		if soa, ok := rr.(*dns.SOA); ok {
+			ttl = soa.Hdr.Ttl
+			zConf.Ttl = formatTtl(ttl)
+			zConf.SOARecord = &SOARecord{
+				NameServer: relative(soa.Ns),
+				Admin:      relative(soa.Mbox),
+				Serial:     uint(soa.Serial),
+				Refresh:    uint(soa.Refresh),
+				Retry:      uint(soa.Retry),
+				Expire:     uint(soa.Expire),
+				Minimum:    uint(soa.Minttl),
+			}
+			break
+		}
+	}
+
+	if zConf.SOARecord == nil {
+		return nil, fmt.Errorf("zone %s has no SOA record", origin)
+	}
+
+	for _, rr := range rrs {
+		header := rr.Header()
+		if header.Rrtype == dns.TypeSOA {
+			continue
+		}
+
+		name := relative(header.Name)
+
+		var record Record
+		if header.Ttl == ttl && header.Class == dns.ClassINET {
+			switch r := rr.(type) {
+			case *dns.NS:
+				if name == "@" {
+					record = NSRecord{Type: "NS", NameServer: relative(r.Ns)}
+				}
+			case *dns.A:
+				record = ARecord{Name: name, Type: "A", Ip: r.A.String()}
+			case *dns.MX:
+				if name == "@" {
+					record = MXRecord{Type: "MX", Priority: uint(r.Preference), EmailServer: relative(r.Mx)}
+				}
+			case *dns.TXT:
+				if name == "@" && len(r.Txt) == 1 && !strings.ContainsAny(r.Txt[0], "\"\\") {
+					record = TXTRecord{Type: "TXT", Value: r.Txt[0]}
+				}
+			case *dns.CNAME:
+				record = CNAMERecord{SrcName: name, Type: "CNAME", DstName: relative(r.Target)}
+			}
+		}
+
+		if record != nil {
+			zConf.Records = append(zConf.Records, record)
+		} else {
+			zConf.Other = append(zConf.Other, rr.String())
+		}
+	}
+
+	return zConf, nil
+}
+
+// Formats a TTL in seconds with the largest unit that divides it, like the TTLs written by the API.
+func formatTtl(ttl uint32) string {
+	units := []struct {
+		seconds uint32
+		suffix  string
+	}{{604800, "w"}, {86400, "d"}, {3600, "h"}}
+
+	for _, unit := range units {
+		if ttl > 0 && ttl%unit.seconds == 0 {
+			return strconv.FormatUint(uint64(ttl/unit.seconds), 10) + unit.suffix
+		}
+	}
+
+	return strconv.FormatUint(uint64(ttl), 10)
+}
@@ -0,0 +1,61 @@
+package parser_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// The fixture has the format BIND writes when it syncs the journal of a dynamic zone to its file.
+func TestParseMasterFile(t *testing.T) {
+	file, err := os.Open("testdata/db.example.com.dynamic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	_, err = parser.ZoneParser.Parse("", file)
+	assert.NotNil(t, err)
+
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	zConf, err := parser.ParseMasterFile("db.example.com", file, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "example.com", zConf.Origin)
+	assert.Equal(t, "1d", zConf.Ttl)
+	assert.Equal(t, &parser.SOARecord{
+		NameServer: "ns1", Admin: "admin", Serial: 2024051502, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+	}, zConf.SOARecord)
+
+	assert.Equal(t, []parser.Record{
+		parser.NSRecord{Type: "NS", NameServer: "ns1"},
+		parser.MXRecord{Type: "MX", Priority: 10, EmailServer: "mail"},
+		parser.TXTRecord{Type: "TXT", Value: "v=spf1 mx -all"},
+		parser.ARecord{Name: "mail", Type: "A", Ip: "10.0.0.3"},
+		parser.ARecord{Name: "ns1", Type: "A", Ip: "10.0.0.1"},
+		parser.ARecord{Name: "sip", Type: "A", Ip: "10.0.0.4"},
+		parser.ARecord{Name: "ns1.sub", Type: "A", Ip: "10.0.1.1"},
+	}, zConf.Records)
+
+	// Records not modeled, or with their own TTL, are kept as they are
+	assert.Equal(t, []string{
+		"_sip._tcp.example.com.\t86400\tIN\tSRV\t0 5 5060 sip.example.com.",
+		"sub.example.com.\t86400\tIN\tNS\tns1.sub.example.com.",
+		"www.example.com.\t300\tIN\tA\t10.0.0.2",
+		"www.example.com.\t300\tIN\tAAAA\t2001:db8::2",
+	}, zConf.Other)
+
+	// The zone is written back without losing records
+	written, err := parser.ParseMasterFile("db.example.com", strings.NewReader(zConf.String()), "example.com")
+	if assert.Nil(t, err) {
+		assert.Equal(t, zConf, written)
+	}
+}
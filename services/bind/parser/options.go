@@ -0,0 +1,165 @@
+package parser
+
+// Common settings of the `options` block. Options not listed here are kept as they are.
+type Options struct {
+	Directory        string   `json:"directory"`
+	Recursion        *bool    `json:"recursion"`
+	Forward          string   `json:"forward"`
+	Forwarders       []string `json:"forwarders"`
+	AllowQuery       []string `json:"allowQuery"`
+	AllowRecursion   []string `json:"allowRecursion"`
+	AllowTransfer    []string `json:"allowTransfer"`
+	DnssecValidation string   `json:"dnssecValidation"`
+	KeyDirectory     string   `json:"keyDirectory"`
+}
+
+func (sf *StatementsFile) optionsBlock() *Block {
+	if statement := sf.Statements.Find("options"); statement != nil && statement.Block() != nil {
+		return statement.Block()
+	}
+	return nil
+}
+
+// Reads the known settings from the `options` block.
+// Settings that are not present are left empty.
+func (sf *StatementsFile) GetOptions() *Options {
+	options := &Options{}
+
+	block := sf.optionsBlock()
+	if block == nil {
+		return options
+	}
+
+	options.Directory = Unquote(getValue(block.Statements, "directory"))
+	options.Forward = getValue(block.Statements, "forward")
+	options.DnssecValidation = getValue(block.Statements, "dnssec-validation")
+	options.KeyDirectory = Unquote(getValue(block.Statements, "key-directory"))
+
+	if recursion := getValue(block.Statements, "recursion"); recursion != "" {
+		enabled := recursion == "yes" || recursion == "true" || recursion == "1"
+		options.Recursion = &enabled
+	}
+
+	options.Forwarders = getList(block.Statements, "forwarders")
+	options.AllowQuery = getList(block.Statements, "allow-query")
+	options.AllowRecursion = getList(block.Statements, "allow-recursion")
+	options.AllowTransfer = getList(block.Statements, "allow-transfer")
+
+	return options
+}
+
+// Writes the known settings into the `options` block, creating it if needed.
+// Empty settings are removed from the block so BIND uses its defaults.
+func (sf *StatementsFile) SetOptions(options *Options) {
+	block := sf.optionsBlock()
+	if block == nil {
+		block = &Block{Statements: Statements{}}
+		sf.Statements = append(sf.Statements, &Statement{Args: []*Arg{{Value: "options"}, {Block: block}}})
+	}
+
+	setString(&block.Statements, "directory", options.Directory)
+	setValue(&block.Statements, "forward", options.Forward)
+	setValue(&block.Statements, "dnssec-validation", options.DnssecValidation)
+	setString(&block.Statements, "key-directory", options.KeyDirectory)
+
+	if options.Recursion == nil {
+		setValue(&block.Statements, "recursion", "")
+	} else if *options.Recursion {
+		setValue(&block.Statements, "recursion", "yes")
+	} else {
+		setValue(&block.Statements, "recursion", "no")
+	}
+
+	setList(&block.Statements, "forwarders", options.Forwarders)
+	setList(&block.Statements, "allow-query", options.AllowQuery)
+	setList(&block.Statements, "allow-recursion", options.AllowRecursion)
+	setList(&block.Statements, "allow-transfer", options.AllowTransfer)
+}
+
+// Returns true if the `allow-new-zones` option lets zones be added with `rndc addzone`.
+func (sf *StatementsFile) AllowsNewZones() bool {
+	block := sf.optionsBlock()
+	if block == nil {
+		return false
+	}
+
+	value := getValue(block.Statements, "allow-new-zones")
+	return value == "yes" || value == "true" || value == "1"
+}
+
+// Returns the zones of the `response-policy` option, in order of precedence.
+func (sf *StatementsFile) ResponsePolicyZones() []string {
+	zones := []string{}
+
+	block := sf.optionsBlock()
+	if block == nil {
+		return zones
+	}
+
+	if statement := block.Statements.Find("response-policy"); statement != nil && statement.Block() != nil {
+		for _, element := range statement.Block().Statements {
+			if element.Name() == "zone" && len(element.Values()) > 0 {
+				zones = append(zones, Unquote(element.Values()[0]))
+			}
+		}
+	}
+
+	return zones
+}
+
+// Appends a zone to the `response-policy` option, creating the option and the `options` block if needed.
+// Other zones of the option and their settings are kept.
+func (sf *StatementsFile) AddResponsePolicyZone(name string) {
+	for _, zone := range sf.ResponsePolicyZones() {
+		if zone == name {
+			return
+		}
+	}
+
+	block := sf.optionsBlock()
+	if block == nil {
+		block = &Block{Statements: Statements{}}
+		sf.Statements = append(sf.Statements, &Statement{Args: []*Arg{{Value: "options"}, {Block: block}}})
+	}
+
+	element := NewStatement("zone", Quote(name))
+
+	if statement := block.Statements.Find("response-policy"); statement != nil && statement.Block() != nil {
+		statement.Block().Statements = append(statement.Block().Statements, element)
+		return
+	}
+
+	block.Statements.Set(NewListStatement("response-policy", []string{element.Inline()}))
+}
+
+// Removes a zone from the `response-policy` option, and the option itself when no zone is left.
+func (sf *StatementsFile) RemoveResponsePolicyZone(name string) {
+	block := sf.optionsBlock()
+	if block == nil {
+		return
+	}
+
+	statement := block.Statements.Find("response-policy")
+	if statement == nil || statement.Block() == nil {
+		return
+	}
+
+	elements := Statements{}
+	zones := 0
+	for _, element := range statement.Block().Statements {
+		if element.Name() == "zone" && len(element.Values()) > 0 {
+			if Unquote(element.Values()[0]) == name {
+				continue
+			}
+			zones++
+		}
+		elements = append(elements, element)
+	}
+
+	if zones == 0 {
+		block.Statements.Remove("response-policy")
+		return
+	}
+
+	statement.Block().Statements = elements
+}
@@ -0,0 +1,54 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestOptions(t *testing.T) {
+	content := `options {
+	// working directory
+	directory "/var/cache/bind";
+	// forwarders { 0.0.0.0; };
+	dnssec-validation auto;
+	listen-on port 53 { 127.0.0.1; };
+	listen-on-v6 { any; };
+};
+`
+
+	conf, err := parser.StatementParser.ParseString("", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := conf.GetOptions()
+
+	assert.Equal(t, "/var/cache/bind", options.Directory)
+	assert.Equal(t, "auto", options.DnssecValidation)
+	assert.Nil(t, options.Recursion)
+	assert.Nil(t, options.Forwarders)
+
+	recursion := false
+	options.Recursion = &recursion
+	options.Forwarders = []string{"8.8.8.8", "1.1.1.1 port 5353"}
+	options.DnssecValidation = ""
+
+	conf.SetOptions(options)
+
+	expected := `options {
+	// working directory
+	directory "/var/cache/bind";
+	listen-on port 53 { 127.0.0.1; };
+	listen-on-v6 { any; };
+	recursion no;
+	forwarders {
+		8.8.8.8;
+		1.1.1.1 port 5353;
+	};
+};
+`
+
+	assert.Equal(t, expected, conf.String())
+}
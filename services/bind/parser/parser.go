@@ -5,7 +5,6 @@ import (
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
-	"github.com/svex99/bind-api/pkg/setting"
 )
 
 var (
@@ -38,11 +37,11 @@ type ZoneConf struct {
 	Ttl       string     `parser:"'$TTL' @Ttl NewLine" json:"ttl"`
 	SOARecord *SOARecord `parser:"@@ NewLine" json:"soaRecord"`
 	Records   []Record   `parser:"@@*" json:"records"`
-}
-
-// TODO: Do not return path from string concatenation
-func (zc *ZoneConf) GetFilename() string {
-	return fmt.Sprintf("%sdb.%s", setting.Bind.LibPath, zc.Origin)
+	// Path of the zone file declared in named.conf, as seen by BIND
+	File string `parser:"" json:"file"`
+	// Records the API does not model, like AAAA or SRV records or records with a TTL of their own,
+	// kept as they are so they are written back with the zone
+	Other []string `parser:"" json:"otherRecords"`
 }
 
 type Record interface {
@@ -59,6 +58,13 @@ type SOARecord struct {
 	Minimum    uint   `parser:"@Uint ')'" json:"minimum"`
 }
 
+func (soa *SOARecord) String() string {
+	return fmt.Sprintf(
+		"@ IN SOA %s %s ( %d %d %d %d %d )\n",
+		soa.NameServer, soa.Admin, soa.Serial, soa.Refresh, soa.Retry, soa.Expire, soa.Minimum,
+	)
+}
+
 type NSRecord struct {
 	Type       string `parser:"'@' 'IN' @'NS'" json:"type"`
 	NameServer string `parser:"@Name NewLine" json:"nameServer"`
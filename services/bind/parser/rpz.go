@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+// Actions of a response policy rule
+const (
+	RPZBlock    = "block"
+	RPZNxdomain = "nxdomain"
+	RPZPassthru = "passthru"
+	RPZRedirect = "redirect"
+)
+
+// CNAME targets with a special meaning in response policy zones, see the BIND ARM section on RPZ.
+var rpzTargets = map[string]string{
+	RPZBlock:    "*.",
+	RPZNxdomain: ".",
+	RPZPassthru: "rpz-passthru.",
+}
+
+// Response policy zone, rewriting the answers of the domains matched by its rules.
+type RPZZone struct {
+	Origin string     `json:"origin"`
+	Serial uint       `json:"serial"`
+	Rules  []*RPZRule `json:"rules"`
+}
+
+type RPZRule struct {
+	Domain string `json:"domain"`
+	Action string `json:"action"`
+	// Domain the answers are redirected to, only for redirect rules
+	Target string `json:"target,omitempty"`
+	// The rule also matches every subdomain of the domain
+	Subdomains bool `json:"subdomains"`
+}
+
+func (r *RPZRule) Validate() error {
+	if r.Domain == "" || strings.HasPrefix(r.Domain, ".") || strings.HasSuffix(r.Domain, ".") {
+		return fmt.Errorf("invalid domain '%s'", r.Domain)
+	}
+
+	if r.Action == RPZRedirect {
+		if r.Target == "" {
+			return fmt.Errorf("redirect rule for %s has no target", r.Domain)
+		}
+	} else if _, ok := rpzTargets[r.Action]; !ok {
+		return fmt.Errorf("invalid action '%s', expected block, nxdomain, passthru or redirect", r.Action)
+	}
+
+	return nil
+}
+
+// Returns the CNAME target of the rule.
+func (r *RPZRule) cname() string {
+	if r.Action == RPZRedirect {
+		return strings.TrimSuffix(r.Target, ".") + "."
+	}
+	return rpzTargets[r.Action]
+}
+
+func (r *RPZRule) String() string {
+	rule := fmt.Sprintf("%s IN CNAME %s\n", r.Domain, r.cname())
+	if r.Subdomains {
+		rule += fmt.Sprintf("*.%s IN CNAME %s\n", r.Domain, r.cname())
+	}
+	return rule
+}
+
+// Parses a response policy zone file made of CNAME rules.
+// A domain and its wildcard with the same target are read as a single rule matching subdomains.
+func ParseRPZZone(origin, content string) (*RPZZone, error) {
+	rz := &RPZZone{Origin: origin, Rules: []*RPZRule{}}
+
+	owners := []string{}
+	targets := map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, ";"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "$") {
+			continue
+		}
+
+		// Skip optional TTL and class
+		owner, rest := fields[0], fields[1:]
+		for len(rest) > 0 && (rest[0] == "IN" || isNumber(rest[0])) {
+			rest = rest[1:]
+		}
+
+		if len(rest) < 2 {
+			continue
+		}
+
+		switch rest[0] {
+		case "SOA":
+			if len(rest) >= 4 {
+				serial, err := strconv.ParseUint(strings.TrimPrefix(rest[3], "("), 10, 64)
+				if err == nil {
+					rz.Serial = uint(serial)
+				}
+			}
+			// Serial may follow the opening parenthesis as a separate field
+			if rz.Serial == 0 && len(rest) >= 5 && rest[3] == "(" {
+				if serial, err := strconv.ParseUint(rest[4], 10, 64); err == nil {
+					rz.Serial = uint(serial)
+				}
+			}
+		case "CNAME":
+			if _, ok := targets[owner]; !ok {
+				owners = append(owners, owner)
+			}
+			targets[owner] = rest[1]
+		}
+	}
+
+	for _, owner := range owners {
+		target := targets[owner]
+
+		if strings.HasPrefix(owner, "*.") {
+			if domainTarget, ok := targets[strings.TrimPrefix(owner, "*.")]; ok && domainTarget == target {
+				// Already included in the rule of the domain
+				continue
+			}
+		}
+
+		rule := &RPZRule{Domain: owner, Action: RPZRedirect, Target: strings.TrimSuffix(target, ".")}
+		for action, actionTarget := range rpzTargets {
+			if target == actionTarget {
+				rule.Action = action
+				rule.Target = ""
+			}
+		}
+
+		if wildcardTarget, ok := targets["*."+owner]; ok && wildcardTarget == target {
+			rule.Subdomains = true
+		}
+
+		rz.Rules = append(rz.Rules, rule)
+	}
+
+	return rz, scanner.Err()
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseUint(value, 10, 64)
+	return err == nil
+}
+
+func (rz *RPZZone) GetRule(domain string) *RPZRule {
+	for _, rule := range rz.Rules {
+		if rule.Domain == domain {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Adds a rule, or replaces the rule of the same domain.
+// Rules are replaced in a new slice so copies of the zone are not affected.
+func (rz *RPZZone) SetRule(rule *RPZRule) {
+	rules := []*RPZRule{}
+	found := false
+
+	for _, r := range rz.Rules {
+		if r.Domain == rule.Domain {
+			rules = append(rules, rule)
+			found = true
+		} else {
+			rules = append(rules, r)
+		}
+	}
+
+	if !found {
+		rules = append(rules, rule)
+	}
+
+	rz.Rules = rules
+}
+
+func (rz *RPZZone) DeleteRule(domain string) error {
+	if rz.GetRule(domain) == nil {
+		return fmt.Errorf("rule for %s does not exist", domain)
+	}
+
+	rules := []*RPZRule{}
+	for _, rule := range rz.Rules {
+		if rule.Domain != domain {
+			rules = append(rules, rule)
+		}
+	}
+
+	rz.Rules = rules
+
+	return nil
+}
+
+func (rz *RPZZone) String() string {
+	var content strings.Builder
+
+	content.WriteString(fmt.Sprintf("$ORIGIN %s.\n", rz.Origin))
+	content.WriteString("$TTL 60\n")
+	content.WriteString(fmt.Sprintf("@ IN SOA localhost. root.localhost. ( %d 3600 600 86400 60 )\n", rz.Serial))
+	content.WriteString("@ IN NS localhost.\n")
+
+	for _, rule := range rz.Rules {
+		content.WriteString(rule.String())
+	}
+
+	return content.String()
+}
+
+// Writes the response policy zone to a plain text file as it is, see ZoneConf.WriteToDisk.
+func (rz *RPZZone) WriteToDisk(tx *file.Transaction, filename string) error {
+	return tx.Write(filename, []byte(rz.String()))
+}
+
+// Generates a new serial for the response policy zone.
+func (rz *RPZZone) UpdateSerial() {
+	rz.Serial = nextSerial(rz.Serial)
+}
+
+func (rz *RPZZone) DeleteFromDisk(tx *file.Transaction, filename string) error {
+	return tx.Remove(filename)
+}
@@ -0,0 +1,100 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestRPZZone(t *testing.T) {
+	rz := &parser.RPZZone{Origin: "rpz.local", Serial: 1, Rules: []*parser.RPZRule{}}
+
+	rz.SetRule(&parser.RPZRule{Domain: "malware.example", Action: parser.RPZBlock, Subdomains: true})
+	rz.SetRule(&parser.RPZRule{Domain: "phishing.example", Action: parser.RPZNxdomain})
+	rz.SetRule(&parser.RPZRule{Domain: "ok.malware.example", Action: parser.RPZPassthru})
+	rz.SetRule(&parser.RPZRule{Domain: "ads.example", Action: parser.RPZRedirect, Target: "sinkhole.example"})
+	rz.SetRule(&parser.RPZRule{Domain: "phishing.example", Action: parser.RPZNxdomain, Subdomains: true})
+
+	assert.Contains(t, rz.String(), "*.malware.example IN CNAME *.\n")
+	assert.Contains(t, rz.String(), "ok.malware.example IN CNAME rpz-passthru.\n")
+	assert.Contains(t, rz.String(), "ads.example IN CNAME sinkhole.example.\n")
+
+	parsed, err := parser.ParseRPZZone("rpz.local", rz.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, rz, parsed)
+
+	assert.Nil(t, parsed.DeleteRule("ads.example"))
+	assert.NotNil(t, parsed.DeleteRule("ads.example"))
+	assert.Len(t, parsed.Rules, 3)
+	assert.Len(t, rz.Rules, 4)
+
+	assert.NotNil(t, (&parser.RPZRule{Domain: "ads.example", Action: parser.RPZRedirect}).Validate())
+	assert.NotNil(t, (&parser.RPZRule{Domain: "ads.example", Action: "drop"}).Validate())
+	assert.Nil(t, (&parser.RPZRule{Domain: "ads.example", Action: parser.RPZBlock}).Validate())
+}
+
+func TestResponsePolicy(t *testing.T) {
+	content := "options {\n\tdirectory \"/var/cache/bind\";\n};\n"
+
+	optionsConf, err := parser.StatementParser.ParseString("named.conf.options", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	optionsConf.AddResponsePolicyZone("rpz.local")
+	optionsConf.AddResponsePolicyZone("rpz.block")
+	optionsConf.AddResponsePolicyZone("rpz.local")
+
+	assert.Equal(t, []string{"rpz.local", "rpz.block"}, optionsConf.ResponsePolicyZones())
+
+	reparsed, err := parser.StatementParser.ParseString("named.conf.options", optionsConf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"rpz.local", "rpz.block"}, reparsed.ResponsePolicyZones())
+
+	reparsed.RemoveResponsePolicyZone("rpz.local")
+	assert.Equal(t, []string{"rpz.block"}, reparsed.ResponsePolicyZones())
+
+	reparsed.RemoveResponsePolicyZone("rpz.block")
+	assert.Equal(t, content, reparsed.String())
+}
+
+func TestBlocklist(t *testing.T) {
+	content := `# Hosts file header
+127.0.0.1 localhost
+::1 localhost ip6-localhost
+0.0.0.0 0.0.0.0
+
+0.0.0.0 ads.example tracker.example # inline comment
+0.0.0.0 Ads.Example.
+malware.example
+not_a*domain
+! adblock comment
+`
+
+	domains, skipped, err := parser.ParseBlocklist(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"ads.example", "tracker.example", "malware.example"}, domains)
+	assert.Equal(t, 1, skipped)
+
+	rz := &parser.RPZZone{Origin: "rpz.local", Rules: []*parser.RPZRule{
+		{Domain: "ads.example", Action: parser.RPZBlock},
+		{Domain: "old.example", Action: parser.RPZNxdomain},
+	}}
+
+	added, removed := rz.ReplaceRules(domains, parser.RPZNxdomain, false)
+
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+	assert.Len(t, rz.Rules, 3)
+	assert.Equal(t, parser.RPZNxdomain, rz.GetRule("ads.example").Action)
+}
@@ -0,0 +1,496 @@
+package parser
+
+import (
+	"io"
+	"strings"
+
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
+	"github.com/svex99/bind-api/pkg/file"
+)
+
+// Maximum length of a block rendered in a single line.
+const maxInlineBlock = 60
+
+var statementGrammar = participle.MustBuild[StatementsFile](
+	participle.Lexer(ConfLexer),
+	participle.Elide("Whitespace", "Comment"),
+	participle.UseLookahead(1),
+)
+
+type statementParser struct{}
+
+// Parses a named.conf file into a generic tree of statements.
+// Strings are kept quoted and every statement remembers the text it was parsed from, including
+// the comments before it, so statements not modified by the API are written back unchanged.
+var StatementParser = &statementParser{}
+
+func (sp *statementParser) Parse(filename string, r io.Reader) (*StatementsFile, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return sp.ParseString(filename, string(content))
+}
+
+func (sp *statementParser) ParseString(filename, content string) (*StatementsFile, error) {
+	sf, err := statementGrammar.ParseString(filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	end := attachSource(content, sf.Statements, 0)
+	sf.trailing = content[end:]
+
+	return sf, nil
+}
+
+func (sp *statementParser) String() string {
+	return statementGrammar.String()
+}
+
+type StatementsFile struct {
+	Statements Statements `parser:"@@*"`
+
+	// whitespace and comments after the last statement
+	trailing string
+}
+
+type Statements []*Statement
+
+// A statement is a list of words and blocks ended by `;`, like `forwarders { 8.8.8.8; };`
+// or `controls { inet 127.0.0.1 allow { localhost; }; };`.
+type Statement struct {
+	Pos    lexer.Position
+	EndPos lexer.Position
+
+	Args []*Arg `parser:"@@+ ';'"`
+
+	// whitespace and comments between the previous statement and this one
+	leading string
+	// text the statement was parsed from, empty for statements created by the API
+	original string
+	// single line rendering of the statement when it was parsed, used to detect changes
+	signature string
+}
+
+type Arg struct {
+	Value string `parser:"  @(Keyword|String|Address|Number|Word|'!')"`
+	Block *Block `parser:"| @@"`
+}
+
+type Block struct {
+	Pos    lexer.Position
+	EndPos lexer.Position
+
+	Statements Statements `parser:"'{' @@* '}'"`
+
+	// whitespace and comments between the last statement and the closing brace
+	trailing string
+	parsed   bool
+}
+
+// Records in every statement the text it was parsed from.
+// Returns the offset where the last statement ends.
+func attachSource(source string, statements Statements, start int) int {
+	for _, statement := range statements {
+		statement.leading = source[start:statement.Pos.Offset]
+		statement.original = source[statement.Pos.Offset:statement.EndPos.Offset]
+		statement.signature = statement.Inline()
+
+		for _, arg := range statement.Args {
+			if arg.Block != nil {
+				end := attachSource(source, arg.Block.Statements, arg.Block.Pos.Offset+1)
+				arg.Block.trailing = source[end : arg.Block.EndPos.Offset-1]
+				arg.Block.parsed = true
+			}
+		}
+
+		start = statement.EndPos.Offset
+	}
+
+	return start
+}
+
+// Builds a statement from plain words, like NewStatement("recursion", "yes").
+func NewStatement(values ...string) *Statement {
+	statement := &Statement{}
+	for _, value := range values {
+		if value != "!" && strings.HasPrefix(value, "!") {
+			statement.Args = append(statement.Args, &Arg{Value: "!"})
+			value = value[1:]
+		}
+		statement.Args = append(statement.Args, &Arg{Value: value})
+	}
+	return statement
+}
+
+// Builds a statement with a trailing block of one line statements, like `forwarders { 1.1.1.1; };`.
+func NewListStatement(name string, elements []string) *Statement {
+	block := &Block{Statements: Statements{}}
+	for _, element := range elements {
+		block.Statements = append(block.Statements, NewStatement(strings.Fields(element)...))
+	}
+
+	statement := NewStatement(name)
+	statement.Args = append(statement.Args, &Arg{Block: block})
+
+	return statement
+}
+
+// Returns the first word of the statement.
+func (s *Statement) Name() string {
+	if len(s.Args) == 0 {
+		return ""
+	}
+	return s.Args[0].Value
+}
+
+// Returns the words that follow the statement name, up to the first block.
+func (s *Statement) Values() []string {
+	values := []string{}
+	for _, arg := range s.Args[1:] {
+		if arg.Block != nil {
+			break
+		}
+		values = append(values, arg.Value)
+	}
+	return values
+}
+
+// Returns the first block of the statement, or nil if it has none.
+func (s *Statement) Block() *Block {
+	for _, arg := range s.Args {
+		if arg.Block != nil {
+			return arg.Block
+		}
+	}
+	return nil
+}
+
+// Returns the elements of the statement block as strings, like the addresses of an address match list.
+func (s *Statement) List() []string {
+	list := []string{}
+
+	block := s.Block()
+	if block == nil {
+		return list
+	}
+
+	for _, statement := range block.Statements {
+		list = append(list, statement.Inline())
+	}
+
+	return list
+}
+
+// Renders the statement in a single line without the ending `;`.
+func (s *Statement) Inline() string {
+	words := []string{}
+	negated := false
+
+	for _, arg := range s.Args {
+		var word string
+
+		if arg.Block != nil {
+			word = arg.Block.inline()
+		} else if arg.Value == "!" {
+			negated = true
+			continue
+		} else {
+			word = arg.Value
+		}
+
+		if negated {
+			word = "!" + word
+			negated = false
+		}
+
+		words = append(words, word)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Returns true if the statement was modified or created after parsing.
+func (s *Statement) changed() bool {
+	return s.original == "" || s.Inline() != s.signature
+}
+
+// Renders the statement preceded by the whitespace and comments it had in the original file.
+func (s *Statement) render(indent string) string {
+	leading := s.leading
+	if s.original == "" && leading == "" {
+		leading = "\n" + indent
+	}
+
+	return leading + s.body(indent)
+}
+
+// Renders the statement alone, unchanged statements are rendered exactly as they were parsed.
+func (s *Statement) body(indent string) string {
+	if !s.changed() {
+		return s.original
+	}
+
+	words := []string{}
+	negated := false
+
+	for _, arg := range s.Args {
+		var word string
+
+		if arg.Block != nil {
+			word = arg.Block.render(indent)
+		} else if arg.Value == "!" {
+			negated = true
+			continue
+		} else {
+			word = arg.Value
+		}
+
+		if negated {
+			word = "!" + word
+			negated = false
+		}
+
+		words = append(words, word)
+	}
+
+	return strings.Join(words, " ") + ";"
+}
+
+func (s *Statement) String() string {
+	return s.body("") + "\n"
+}
+
+func (s *Statement) copy() *Statement {
+	copied := &Statement{
+		Pos:       s.Pos,
+		EndPos:    s.EndPos,
+		leading:   s.leading,
+		original:  s.original,
+		signature: s.signature,
+	}
+
+	for _, arg := range s.Args {
+		if arg.Block != nil {
+			copied.Args = append(copied.Args, &Arg{Block: arg.Block.copy()})
+		} else {
+			copied.Args = append(copied.Args, &Arg{Value: arg.Value})
+		}
+	}
+
+	return copied
+}
+
+func (b *Block) inline() string {
+	elements := []string{"{"}
+	for _, statement := range b.Statements {
+		elements = append(elements, statement.Inline()+";")
+	}
+	return strings.Join(append(elements, "}"), " ")
+}
+
+// Blocks created by the API with short single word elements are rendered in a single line,
+// like `{ any; }`, the rest are rendered with one statement per line.
+func (b *Block) render(indent string) string {
+	if !b.parsed {
+		simple := true
+		for _, statement := range b.Statements {
+			simple = simple && len(strings.Fields(statement.Inline())) == 1
+		}
+
+		if inline := b.inline(); simple && len(indent+inline) <= maxInlineBlock {
+			return inline
+		}
+	}
+
+	content := ""
+	for _, statement := range b.Statements {
+		content += statement.render(indent + "\t")
+	}
+
+	trailing := b.trailing
+	if !b.parsed {
+		trailing = "\n" + indent
+	}
+
+	return "{" + content + trailing + "}"
+}
+
+func (b *Block) copy() *Block {
+	return &Block{
+		Pos:        b.Pos,
+		EndPos:     b.EndPos,
+		Statements: b.Statements.Copy(),
+		trailing:   b.trailing,
+		parsed:     b.parsed,
+	}
+}
+
+// Returns a deep copy of the statements, so they can be modified without affecting the original.
+func (ss Statements) Copy() Statements {
+	statements := Statements{}
+	for _, statement := range ss {
+		statements = append(statements, statement.copy())
+	}
+	return statements
+}
+
+// Returns the first statement with the given name.
+func (ss Statements) Find(name string) *Statement {
+	for _, statement := range ss {
+		if statement.Name() == name {
+			return statement
+		}
+	}
+	return nil
+}
+
+// Replaces the first statement with the same name, or appends it if there is none.
+// The replaced statement is kept if both render the same, and otherwise the new one
+// takes its place and preceding comments.
+func (ss *Statements) Set(statement *Statement) {
+	for i, s := range *ss {
+		if s.Name() == statement.Name() {
+			if s.Inline() != statement.Inline() {
+				if statement.original == "" {
+					statement.leading = s.leading
+				}
+				(*ss)[i] = statement
+			}
+			return
+		}
+	}
+	*ss = append(*ss, statement)
+}
+
+// Removes every statement with the given name, along with the comments that precede it.
+func (ss *Statements) Remove(name string) {
+	statements := Statements{}
+	for _, statement := range *ss {
+		if statement.Name() != name {
+			statements = append(statements, statement)
+		}
+	}
+	*ss = statements
+}
+
+func (ss Statements) render(indent string) string {
+	content := ""
+	for _, statement := range ss {
+		content += statement.render(indent)
+	}
+	return content
+}
+
+func (sf *StatementsFile) Copy() *StatementsFile {
+	return &StatementsFile{Statements: sf.Statements.Copy(), trailing: sf.trailing}
+}
+
+// Renders the file, keeping the original text of unchanged statements.
+// Top level statements created by the API are separated by an empty line.
+func (sf *StatementsFile) String() string {
+	content := ""
+	for _, statement := range sf.Statements {
+		switch {
+		case statement.original != "" || statement.leading != "":
+			content += statement.render("")
+		case content == "":
+			content += statement.body("")
+		default:
+			content += "\n\n" + statement.body("")
+		}
+	}
+
+	if content != "" && !strings.Contains(sf.trailing, "\n") {
+		return content + sf.trailing + "\n"
+	}
+
+	return content + sf.trailing
+}
+
+func (sf *StatementsFile) WriteToDisk(tx *file.Transaction, filename string) error {
+	return tx.Write(filename, []byte(sf.String()))
+}
+
+func getValue(statements Statements, name string) string {
+	statement := statements.Find(name)
+	if statement == nil || len(statement.Values()) == 0 {
+		return ""
+	}
+	return statement.Values()[0]
+}
+
+func getList(statements Statements, name string) []string {
+	statement := statements.Find(name)
+	if statement == nil {
+		return nil
+	}
+	return statement.List()
+}
+
+// Sets a single value statement, or removes it if value is empty.
+func setValue(statements *Statements, name, value string) {
+	if value == "" {
+		statements.Remove(name)
+	} else {
+		statements.Set(NewStatement(name, value))
+	}
+}
+
+// Sets a single quoted value statement, or removes it if value is empty.
+// An existing statement with the same value is kept even if it is not quoted.
+func setString(statements *Statements, name, value string) {
+	if Unquote(getValue(*statements, name)) == value && value != "" {
+		return
+	}
+
+	if value == "" {
+		setValue(statements, name, "")
+	} else {
+		setValue(statements, name, Quote(value))
+	}
+}
+
+// Sets a list statement, or removes it if the list is nil or empty.
+// Words before the block of an existing statement, like `port 53`, are preserved.
+func setList(statements *Statements, name string, list []string) {
+	if len(list) == 0 {
+		statements.Remove(name)
+		return
+	}
+
+	statement := NewListStatement(name, list)
+
+	if current := statements.Find(name); current != nil && current.Block() != nil {
+		if current.Block().inline() != statement.Block().inline() {
+			*current.Block() = *statement.Block()
+		}
+		return
+	}
+
+	statements.Set(statement)
+}
+
+// Returns the names of the top level `dnssec-policy` statements.
+func (sf *StatementsFile) DnssecPolicies() []string {
+	policies := []string{}
+	for _, statement := range sf.Statements {
+		if statement.Name() == "dnssec-policy" && statement.Block() != nil {
+			policies = append(policies, statementName(statement))
+		}
+	}
+	return policies
+}
+
+// Removes the quotes of a string value.
+func Unquote(value string) string {
+	return strings.Trim(value, `"`)
+}
+
+// Quotes a string value.
+func Quote(value string) string {
+	return `"` + value + `"`
+}
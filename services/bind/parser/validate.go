@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem found in the records of a zone.
+type Issue struct {
+	Severity string `json:"severity"`
+	// Owner name of the records with the problem, relative to the origin
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("%s %s: %s", i.Severity, i.Name, i.Message)
+}
+
+// Error returned when a change introduces issues in a zone.
+type ValidationError struct {
+	Origin string   `json:"origin"`
+	Issues []*Issue `json:"issues"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.String())
+	}
+	return fmt.Sprintf("zone %s is not valid: %s", e.Origin, strings.Join(messages, "; "))
+}
+
+// Checks the records of the zone for mistakes that BIND accepts or only warns about.
+// Issues are returned in the order of the records that cause them.
+func (zc *ZoneConf) Validate() []*Issue {
+	issues := []*Issue{}
+	addIssue := func(severity, name, format string, args ...any) {
+		issues = append(issues, &Issue{Severity: severity, Name: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Types of the records of every owner name, and targets of the CNAME records
+	types := map[string]map[string]bool{}
+	cnames := map[string]string{}
+	seen := map[string]bool{}
+
+	for _, record := range zc.Records {
+		name, rType := zc.recordOwner(record)
+
+		if seen[record.String()] {
+			addIssue(SeverityWarning, name, "duplicate %s record '%s'", rType, strings.TrimSpace(record.String()))
+			continue
+		}
+		seen[record.String()] = true
+
+		if types[name] == nil {
+			types[name] = map[string]bool{}
+		}
+		types[name][rType] = true
+
+		if cname, ok := record.(CNAMERecord); ok {
+			if _, exists := cnames[name]; exists {
+				addIssue(SeverityError, name, "multiple CNAME records")
+			}
+			cnames[name] = cname.DstName
+		}
+	}
+
+	for _, record := range zc.Records {
+		name, rType := zc.recordOwner(record)
+
+		if rType == "CNAME" {
+			if name == "@" || len(types[name]) > 1 {
+				addIssue(SeverityError, name, "CNAME record next to other data")
+			}
+		}
+
+		switch r := record.(type) {
+		case NSRecord:
+			zc.checkTarget(r.NameServer, "NS", SeverityError, types, cnames, addIssue)
+		case MXRecord:
+			zc.checkTarget(r.EmailServer, "MX", SeverityWarning, types, cnames, addIssue)
+		case CNAMERecord:
+			target, inZone := zc.relativeName(r.DstName)
+			if inZone && len(types[target]) == 0 {
+				addIssue(SeverityWarning, name, "CNAME target %s has no records in the zone", r.DstName)
+			}
+		}
+	}
+
+	return issues
+}
+
+// Checks that the in-zone target of an NS or MX record has an address and is not an alias.
+func (zc *ZoneConf) checkTarget(
+	target, rType, severity string,
+	types map[string]map[string]bool, cnames map[string]string,
+	addIssue func(severity, name, format string, args ...any),
+) {
+	name, inZone := zc.relativeName(target)
+	if !inZone {
+		return
+	}
+
+	if _, ok := cnames[name]; ok {
+		addIssue(severity, "@", "%s target %s is a CNAME", rType, target)
+	} else if !types[name]["A"] {
+		addIssue(severity, "@", "%s target %s has no address records", rType, target)
+	}
+}
+
+func (zc *ZoneConf) recordOwner(record Record) (string, string) {
+	switch r := record.(type) {
+	case ARecord:
+		name, _ := zc.relativeName(r.Name)
+		return name, "A"
+	case CNAMERecord:
+		name, _ := zc.relativeName(r.SrcName)
+		return name, "CNAME"
+	case NSRecord:
+		return "@", "NS"
+	case MXRecord:
+		return "@", "MX"
+	case TXTRecord:
+		return "@", "TXT"
+	}
+	return "@", fmt.Sprintf("%T", record)
+}
+
+// Returns the name relative to the origin, and false if it is out of the zone.
+func (zc *ZoneConf) relativeName(name string) (string, bool) {
+	name = strings.ToLower(name)
+	origin := strings.ToLower(zc.Origin)
+
+	if !strings.HasSuffix(name, ".") {
+		return name, true
+	}
+
+	name = strings.TrimSuffix(name, ".")
+	if name == origin {
+		return "@", true
+	}
+	if strings.HasSuffix(name, "."+origin) {
+		return strings.TrimSuffix(name, "."+origin), true
+	}
+
+	return name, false
+}
+
+// Returns the issues of the zone that are not present in `previous`.
+func NewIssues(previous, current []*Issue) []*Issue {
+	existing := map[string]bool{}
+	for _, issue := range previous {
+		existing[issue.String()] = true
+	}
+
+	issues := []*Issue{}
+	for _, issue := range current {
+		if !existing[issue.String()] {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,43 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestValidate(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin"},
+		Records: []parser.Record{
+			parser.NSRecord{Type: "NS", NameServer: "ns1"},
+			parser.NSRecord{Type: "NS", NameServer: "ns2"},
+			parser.NSRecord{Type: "NS", NameServer: "ns.example.net."},
+			parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"},
+			parser.MXRecord{Type: "MX", Priority: 10, EmailServer: "mail"},
+			parser.MXRecord{Type: "MX", Priority: 20, EmailServer: "mx2"},
+			parser.CNAMERecord{Type: "CNAME", SrcName: "mail", DstName: "ns1"},
+			parser.CNAMERecord{Type: "CNAME", SrcName: "www", DstName: "web"},
+			parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.2"},
+			parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"},
+		},
+	}
+
+	assert.Equal(t, []*parser.Issue{
+		{Severity: parser.SeverityWarning, Name: "ns1", Message: "duplicate A record 'ns1 IN A 10.0.0.1'"},
+		{Severity: parser.SeverityError, Name: "@", Message: "NS target ns2 has no address records"},
+		{Severity: parser.SeverityWarning, Name: "@", Message: "MX target mail is a CNAME"},
+		{Severity: parser.SeverityWarning, Name: "@", Message: "MX target mx2 has no address records"},
+		{Severity: parser.SeverityError, Name: "www", Message: "CNAME record next to other data"},
+		{Severity: parser.SeverityWarning, Name: "www", Message: "CNAME target web has no records in the zone"},
+	}, zConf.Validate())
+
+	fixed := *zConf
+	fixed.Records = zConf.Records[:4]
+
+	assert.Len(t, parser.NewIssues(zConf.Validate(), fixed.Validate()), 0)
+	assert.Len(t, parser.NewIssues(fixed.Validate(), zConf.Validate()), 5)
+}
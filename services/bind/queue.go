@@ -0,0 +1,264 @@
+package bind
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+const (
+	ChangePending = "pending"
+	ChangeApplied = "applied"
+	ChangeFailed  = "failed"
+)
+
+// Finished changes kept to be polled, the oldest ones are forgotten first
+const keptChanges = 1000
+
+// Record changes waiting to be written in write-behind mode. The changes of a zone received within
+// the window since the first one are written and reloaded together.
+type changeQueue struct {
+	window  time.Duration
+	mutex   *sync.Mutex
+	batches map[string]*changeBatch
+	changes map[string]*queuedChange
+	// Ids of the finished changes, oldest first
+	finished []string
+}
+
+type changeBatch struct {
+	// Zone with the queued changes applied, new changes are validated against it
+	zConf   *parser.ZoneConf
+	changes []*queuedChange
+}
+
+type queuedChange struct {
+	status schemas.QueuedChange
+	apply  func(zConf *parser.ZoneConf) error
+	err    error
+	// Closed once the change is applied or failed
+	done chan struct{}
+}
+
+func newChangeQueue(window time.Duration) *changeQueue {
+	return &changeQueue{
+		window:   window,
+		mutex:    &sync.Mutex{},
+		batches:  map[string]*changeBatch{},
+		changes:  map[string]*queuedChange{},
+		finished: []string{},
+	}
+}
+
+func newChangeId() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Queues a record change of the zone, validated over the changes queued before.
+// The caller must hold the lock of the zone.
+func (bs *BindService) queueChange(zConf *parser.ZoneConf, apply func(zConf *parser.ZoneConf) error) (*queuedChange, error) {
+	q := bs.queue
+
+	id, err := newChangeId()
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	batch, ok := q.batches[zConf.Origin]
+	if !ok {
+		batch = &changeBatch{zConf: zConf}
+	}
+
+	previous := batch.zConf.Validate()
+	changed := *batch.zConf
+
+	if err := apply(&changed); err != nil {
+		return nil, err
+	}
+
+	if err := bs.validateZone(previous, &changed); err != nil {
+		return nil, err
+	}
+
+	change := &queuedChange{
+		status: schemas.QueuedChange{Id: id, Origin: zConf.Origin, Status: ChangePending},
+		apply:  apply,
+		done:   make(chan struct{}),
+	}
+
+	batch.zConf = &changed
+	batch.changes = append(batch.changes, change)
+	q.changes[id] = change
+
+	if !ok {
+		q.batches[zConf.Origin] = batch
+		time.AfterFunc(q.window, func() { bs.flush(zConf.Origin) })
+	}
+
+	return change, nil
+}
+
+// Writes the queued changes of the zone and reloads it once. The changes are applied again over
+// the zone as loaded, since a dynamic zone may have received updates since they were queued.
+// The changes are finished once the batch is recorded in the git store.
+func (bs *BindService) flush(origin string) {
+	unlock := bs.lockZone(origin)
+
+	q := bs.queue
+
+	q.mutex.Lock()
+	batch := q.batches[origin]
+	delete(q.batches, origin)
+	q.mutex.Unlock()
+
+	files, err := bs.applyBatch(origin, batch)
+	unlock()
+
+	applied := 0
+	for _, change := range batch.changes {
+		if change.err == nil {
+			change.err = err
+		}
+		if change.err == nil {
+			applied++
+		}
+	}
+
+	if err != nil {
+		log.Printf("Error applying %d queued change(s) of zone %s: %s\n", len(batch.changes), origin, err)
+	}
+
+	// Batches are written out of the requests that queued them, so their files are recorded on their own
+	if bs.Git != nil && applied > 0 && len(files) > 0 {
+		change := gitstore.Change{
+			Actor:    "bind-api",
+			Endpoint: "write-behind",
+			Message:  fmt.Sprintf("Apply %d queued change(s) of zone %s", applied, origin),
+		}
+		if err := bs.commitFiles(files, change); err != nil {
+			log.Printf("Error recording queued changes of zone %s: %s\n", origin, err)
+		}
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, change := range batch.changes {
+		if change.err != nil {
+			change.status.Status = ChangeFailed
+			change.status.Error = change.err.Error()
+		} else {
+			change.status.Status = ChangeApplied
+		}
+
+		q.finished = append(q.finished, change.status.Id)
+		close(change.done)
+	}
+
+	for len(q.finished) > keptChanges {
+		delete(q.changes, q.finished[0])
+		q.finished = q.finished[1:]
+	}
+}
+
+// Applies the changes of the batch to the zone and writes it, returns the written files. Changes that
+// no longer apply are failed on their own, an error of the zone fails the whole batch.
+func (bs *BindService) applyBatch(origin string, batch *changeBatch) ([]string, error) {
+	targetZConf, ok := bs.zone(origin)
+	if !ok {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	edit, err := bs.editZone(targetZConf)
+	if err != nil {
+		return nil, err
+	}
+	defer edit.thaw()
+
+	files := []string{}
+	edit.onCommit = func(written []string) { files = written }
+
+	previous := edit.zConf.Validate()
+	zConf := *edit.zConf
+
+	for _, change := range batch.changes {
+		change.err = change.apply(&zConf)
+	}
+
+	if err := bs.validateZone(previous, &zConf); err != nil {
+		return nil, err
+	}
+
+	if err := bs.writeZone(edit, &zConf); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Waits until the change is applied and returns its error. A nil change was applied already.
+func (bs *BindService) waitChange(change *queuedChange, err error) error {
+	if err != nil || change == nil {
+		return err
+	}
+
+	<-change.done
+
+	return change.err
+}
+
+// Returns the status of the change, nil if it was applied already.
+func (bs *BindService) changeStatus(change *queuedChange, err error) (*schemas.QueuedChange, error) {
+	if err != nil || change == nil {
+		return nil, err
+	}
+
+	bs.queue.mutex.Lock()
+	defer bs.queue.mutex.Unlock()
+
+	status := change.status
+
+	return &status, nil
+}
+
+func (bs *BindService) GetQueuedChange(id string) (*schemas.QueuedChange, error) {
+	if bs.queue == nil {
+		return nil, fmt.Errorf("change %s does not exist", id)
+	}
+
+	bs.queue.mutex.Lock()
+	change, ok := bs.queue.changes[id]
+	bs.queue.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("change %s does not exist", id)
+	}
+
+	return bs.changeStatus(change, nil)
+}
+
+func (bs *BindService) QueueAddRecord(origin string, record parser.Record) (*schemas.QueuedChange, error) {
+	return bs.changeStatus(bs.addRecord(origin, record))
+}
+
+func (bs *BindService) QueueUpdateRecord(origin, target string, record parser.Record) (*schemas.QueuedChange, error) {
+	return bs.changeStatus(bs.updateRecord(origin, target, record))
+}
+
+func (bs *BindService) QueueDeleteRecord(origin string, record parser.Record) (*schemas.QueuedChange, error) {
+	return bs.changeStatus(bs.deleteRecord(origin, record))
+}
@@ -0,0 +1,133 @@
+package bind_test
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestWriteBehind(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records: []parser.Record{
+			parser.NSRecord{Type: "NS", NameServer: "ns1"},
+			parser.ARecord{Type: "A", Name: "ns1", Ip: "10.0.0.1"},
+		},
+	}
+
+	bindService, runner := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.WriteBehind = 200 * time.Millisecond
+	})
+
+	// Changes waiting for the batch are written and reloaded together
+	wg := sync.WaitGroup{}
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			record := parser.ARecord{Type: "A", Name: fmt.Sprintf("host%d", i), Ip: fmt.Sprintf("10.0.1.%d", i)}
+			assert.Nil(t, bindService.AddRecord("example.com", record))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, runner.Count("rndc reload example.com"))
+
+	zone, err := bindService.GetZone("example.com")
+	assert.Nil(t, err)
+	assert.Len(t, zone.Records, 22)
+
+	content, _ := os.ReadFile(bindService.Setting.LibPath + "db.example.com")
+	assert.Contains(t, string(content), "host20 IN A 10.0.1.20")
+
+	// Queued changes are validated against the ones queued before them
+	record := parser.ARecord{Type: "A", Name: "www", Ip: "10.0.2.1"}
+
+	queued, err := bindService.QueueAddRecord("example.com", record)
+	if assert.Nil(t, err) {
+		assert.Equal(t, bind.ChangePending, queued.Status)
+	}
+
+	_, err = bindService.QueueAddRecord("example.com", record)
+	assert.NotNil(t, err)
+
+	assert.Eventually(t, func() bool {
+		status, err := bindService.GetQueuedChange(queued.Id)
+		return err == nil && status.Status == bind.ChangeApplied
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, 2, runner.Count("rndc reload example.com"))
+
+	_, err = bindService.GetQueuedChange("unknown")
+	assert.NotNil(t, err)
+}
+
+func TestWriteBehindCommits(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records:   []parser.Record{parser.NSRecord{Type: "NS", NameServer: "ns1"}},
+	}
+
+	gitDir := t.TempDir()
+
+	bindService, _ := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local":   "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.WriteBehind = 100 * time.Millisecond
+		bindSetting.GitDir = gitDir
+	})
+
+	queued, err := bindService.QueueAddRecord("example.com", parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.1"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// A request that changes other files while the batch waits
+	_, err = bindService.CreateZone(&schemas.ZoneData{
+		Origin: "example.org", Ttl: "1d", NameServer: "ns1", Admin: "admin",
+		Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+	})
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		status, err := bindService.GetQueuedChange(queued.Id)
+		return err == nil && status.Status == bind.ChangeApplied
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// The batch commits its own files, the ones of the request are recorded with the request
+	commits, err := bindService.ListChanges("example.com", 10)
+	if assert.Nil(t, err) && assert.NotEmpty(t, commits) {
+		assert.Equal(t, "write-behind", commits[0].Endpoint)
+	}
+
+	commits, err = bindService.ListChanges("example.org", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, commits)
+
+	assert.Nil(t, bindService.RecordChange(gitstore.Change{Actor: "alice", Endpoint: "POST /api/zones"}))
+
+	commits, err = bindService.ListChanges("example.org", 10)
+	if assert.Nil(t, err) && assert.Len(t, commits, 1) {
+		assert.Equal(t, "alice", commits[0].Actor)
+	}
+}
@@ -0,0 +1,78 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/svex99/bind-api/pkg/rndc"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Creates a client of the control channel in the settings, with the first key of the rndc key file.
+func newRndcClient(bindSetting *setting.BindSetting) (*rndc.Client, error) {
+	keyFile := bindSetting.RndcKeyFile
+	if keyFile == "" {
+		keyFile = bindSetting.ConfPath + "rndc.key"
+	}
+
+	file, err := os.Open(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	keyConf, err := parser.ConfParser.Parse(keyFile, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyConf.Keys) == 0 {
+		return nil, fmt.Errorf("no key found in %s", keyFile)
+	}
+
+	key := keyConf.Keys[0]
+
+	return rndc.NewClient(bindSetting.RndcAddress, key.Algorithm, key.Secret)
+}
+
+// Runs an rndc command, through the control channel if it is configured or with the rndc tool otherwise.
+// Failures of the command are returned as *rndc.Error either way.
+func (bs *BindService) control(args ...string) (string, error) {
+	if bs.Rndc != nil {
+		return bs.Rndc.Call(strings.Join(args, " "))
+	}
+
+	output, err := bs.exec(append([]string{"rndc"}, args...)...)
+
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return output, rndcError(strings.Join(args, " "), cmdErr)
+	}
+
+	return output, err
+}
+
+// Converts the failure of the rndc tool, which reports errors like `rndc: 'reload' failed: not found`.
+func rndcError(command string, cmdErr *CommandError) *rndc.Error {
+	message := strings.TrimSpace(cmdErr.Stderr)
+
+	lines := strings.Split(message, "\n")
+	for _, line := range lines {
+		if _, reason, found := strings.Cut(line, "' failed: "); found {
+			message = reason
+			break
+		}
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("exit status %d", cmdErr.ExitCode)
+	} else {
+		message = strings.TrimPrefix(message, "rndc: ")
+	}
+
+	return &rndc.Error{Command: command, Result: strconv.Itoa(cmdErr.ExitCode), Message: message}
+}
@@ -0,0 +1,310 @@
+package bind
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func (bs *BindService) loadRPZone(zone *parser.Zone) (*parser.RPZZone, error) {
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rz, err := parser.ParseRPZZone(zone.Name, string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Loaded response policy zone %s with %d rule(s)\n", zone.Name, len(rz.Rules))
+
+	return rz, nil
+}
+
+// Returns the response policy zones in order of precedence.
+func (bs *BindService) ListRPZones() []*parser.RPZZone {
+	bs.Mutex.RLock()
+	defer bs.Mutex.RUnlock()
+
+	current := bs.rpZones()
+
+	rpZones := []*parser.RPZZone{}
+	for _, name := range bs.OptionsConf.ResponsePolicyZones() {
+		if rz, ok := current[name]; ok {
+			rpZones = append(rpZones, rz)
+		}
+	}
+
+	return rpZones
+}
+
+func (bs *BindService) GetRPZone(name string) (*parser.RPZZone, error) {
+	rz, ok := bs.rpZones()[name]
+	if !ok {
+		return nil, fmt.Errorf("response policy zone %s does not exist", name)
+	}
+
+	return rz, nil
+}
+
+// Creates an empty response policy zone and appends it to the `response-policy` option,
+// so it has the lowest precedence among the existing ones.
+func (bs *BindService) CreateRPZone(data *schemas.RPZData) (*parser.RPZZone, error) {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	if bs.BindConf.GetZone(data.Name) != nil {
+		return nil, fmt.Errorf("zone %s exists already", data.Name)
+	}
+
+	rz := &parser.RPZZone{Origin: data.Name, Rules: []*parser.RPZRule{}}
+
+	// Policy zones are only meant to be read by the server itself
+	zone := &parser.Zone{
+		Name:       data.Name,
+		Type:       "master",
+		File:       path.Join(bs.zonesDir(), "db."+data.Name),
+		AllowQuery: []*parser.AddressMatch{{Value: "none"}},
+	}
+
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return nil, err
+	}
+
+	bindConf := *bs.BindConf
+	bindConf.Zones = append(append([]*parser.Zone{}, bs.BindConf.Zones...), zone)
+
+	optionsConf := bs.OptionsConf.Copy()
+	optionsConf.AddResponsePolicyZone(data.Name)
+
+	rz.UpdateSerial()
+
+	if err := bs.checkZone(rz.Origin, filename, rz); err != nil {
+		return nil, err
+	}
+
+	if err := bs.checkConf(&bindConf, optionsConf); err != nil {
+		return nil, err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := rz.WriteToDisk(tx, filename); err != nil {
+		return nil, err
+	}
+
+	if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+		return nil, err
+	}
+
+	if err := optionsConf.WriteToDisk(tx, bs.OptionsFilePath); err != nil {
+		return nil, err
+	}
+
+	if err := bs.Reconfig(); err != nil {
+		return nil, err
+	}
+
+	tx.Commit()
+
+	bs.BindConf = &bindConf
+	bs.OptionsConf = optionsConf
+	bs.setRPZone(rz.Origin, rz)
+
+	return rz, nil
+}
+
+// Removes the response policy zone from the `response-policy` option and deletes it.
+func (bs *BindService) DeleteRPZone(name string) error {
+	bs.Mutex.Lock()
+	defer bs.Mutex.Unlock()
+
+	rz, ok := bs.rpZones()[name]
+	if !ok {
+		return fmt.Errorf("response policy zone %s does not exist", name)
+	}
+
+	zone := bs.BindConf.GetZone(name)
+	if zone == nil {
+		return fmt.Errorf("response policy zone %s is not configured", name)
+	}
+
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return err
+	}
+
+	bindConf := *bs.BindConf
+	bindConf.Zones = []*parser.Zone{}
+	for _, z := range bs.BindConf.Zones {
+		if z.Name != name {
+			bindConf.Zones = append(bindConf.Zones, z)
+		}
+	}
+
+	optionsConf := bs.OptionsConf.Copy()
+	optionsConf.RemoveResponsePolicyZone(name)
+
+	if err := bs.checkConf(&bindConf, optionsConf); err != nil {
+		return err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := rz.DeleteFromDisk(tx, filename); err != nil {
+		return err
+	}
+
+	if err := bindConf.WriteToDisk(tx, bs.ZonesFilePath); err != nil {
+		return err
+	}
+
+	if err := optionsConf.WriteToDisk(tx, bs.OptionsFilePath); err != nil {
+		return err
+	}
+
+	if err := bs.Reconfig(); err != nil {
+		return err
+	}
+
+	tx.Commit()
+
+	bs.BindConf = &bindConf
+	bs.OptionsConf = optionsConf
+	bs.setRPZone(name, nil)
+
+	return nil
+}
+
+// Adds a rule to the response policy zone, replacing the rule of the same domain if any.
+func (bs *BindService) SetRPZRule(name string, data *schemas.RPZRuleData) (*parser.RPZRule, error) {
+	rule := &parser.RPZRule{
+		Domain:     data.Domain,
+		Action:     data.Action,
+		Target:     data.Target,
+		Subdomains: data.Subdomains,
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	unlock := bs.lockZone(name)
+	defer unlock()
+
+	err := bs.updateRPZone(name, func(rz *parser.RPZZone) error {
+		rz.SetRule(rule)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (bs *BindService) DeleteRPZRule(name, domain string) error {
+	unlock := bs.lockZone(name)
+	defer unlock()
+
+	return bs.updateRPZone(name, func(rz *parser.RPZZone) error {
+		return rz.DeleteRule(domain)
+	})
+}
+
+// Writes a copy of the response policy zone with the changes applied by `update` and reloads it.
+func (bs *BindService) updateRPZone(name string, update func(rz *parser.RPZZone) error) error {
+	current, ok := bs.rpZones()[name]
+	if !ok {
+		return fmt.Errorf("response policy zone %s does not exist", name)
+	}
+
+	zone := bs.BindConf.GetZone(name)
+	if zone == nil {
+		return fmt.Errorf("response policy zone %s is not configured", name)
+	}
+
+	rz := *current
+	if err := update(&rz); err != nil {
+		return err
+	}
+
+	filename, err := bs.resolvePath(zone.File)
+	if err != nil {
+		return err
+	}
+
+	rz.UpdateSerial()
+
+	if err := bs.checkZone(name, filename, &rz); err != nil {
+		return err
+	}
+
+	tx, err := bs.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := rz.WriteToDisk(tx, filename); err != nil {
+		return err
+	}
+
+	if err := bs.ReloadZone(name); err != nil {
+		return err
+	}
+
+	tx.Commit()
+
+	bs.setRPZone(name, &rz)
+
+	return nil
+}
+
+// Rebuilds the response policy zone from a blocklist in hosts or domain list format.
+// The whole zone is written and reloaded once, rules of domains not in the list are removed.
+func (bs *BindService) ImportBlocklist(name string, data *schemas.BlocklistData, r io.Reader) (*schemas.BlocklistResult, error) {
+	domains, skipped, err := parser.ParseBlocklist(r)
+	if err != nil {
+		return nil, err
+	}
+
+	action := data.Action
+	if action == "" {
+		action = parser.RPZNxdomain
+	}
+
+	result := &schemas.BlocklistResult{Total: len(domains), Skipped: skipped}
+
+	unlock := bs.lockZone(name)
+	defer unlock()
+
+	err = bs.updateRPZone(name, func(rz *parser.RPZZone) error {
+		result.Added, result.Removed = rz.ReplaceRules(domains, action, data.Subdomains)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
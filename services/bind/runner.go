@@ -0,0 +1,174 @@
+package bind
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/svex99/bind-api/pkg/setting"
+)
+
+// Runs commands, like rndc or named-checkzone, where BIND is installed.
+type Runner interface {
+	// Returns the standard output of the command
+	Run(command ...string) (string, error)
+}
+
+// Error of a command that exited with a non zero status.
+type CommandError struct {
+	Command  []string
+	ExitCode int
+	Stderr   string
+}
+
+func (e *CommandError) Error() string {
+	message := strings.TrimSpace(e.Stderr)
+	if message == "" {
+		message = fmt.Sprintf("exit status %d", e.ExitCode)
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(e.Command, " "), message)
+}
+
+const defaultCommandTimeout = 30 * time.Second
+
+// Creates the runner selected by the `Runner` setting, docker by default.
+func newRunner(bindSetting *setting.BindSetting) (Runner, error) {
+	timeout := bindSetting.CommandTimeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+
+	switch bindSetting.Runner {
+	case "", "docker":
+		cli, err := client.NewClientWithOpts(client.FromEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &DockerRunner{Client: cli, ContainerId: bindSetting.ContainerId, Timeout: timeout}, nil
+	case "local":
+		return &LocalRunner{Timeout: timeout}, nil
+	}
+
+	return nil, fmt.Errorf("unknown runner %s, expected docker or local", bindSetting.Runner)
+}
+
+// Runs commands on the host of the API, used when BIND runs on the same host or container.
+type LocalRunner struct {
+	Timeout time.Duration
+}
+
+func (lr *LocalRunner) Run(command ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lr.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return stdout.String(), fmt.Errorf("%s: timed out after %s", strings.Join(command, " "), lr.Timeout)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), &CommandError{Command: command, ExitCode: exitErr.ExitCode(), Stderr: stderr.String()}
+	} else if err != nil {
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+// Runs commands in the BIND container through the Docker API.
+type DockerRunner struct {
+	Client      *client.Client
+	ContainerId string
+	Timeout     time.Duration
+}
+
+func (dr *DockerRunner) Run(command ...string) (string, error) {
+	// was used as reference for this method the docker-cli exec command implementation
+	// https://github.com/docker/cli/blob/1163b4609978e0e6f2b2629b59c4a62d348e1466/cli/command/container/exec.go#L99
+
+	ctx, cancel := context.WithTimeout(context.Background(), dr.Timeout)
+	defer cancel()
+
+	if _, err := dr.Client.ContainerInspect(ctx, dr.ContainerId); err != nil {
+		return "", err
+	}
+
+	execCreateConfig := &types.ExecConfig{
+		User:         "bind",
+		Privileged:   false,
+		Tty:          false,
+		AttachStdin:  false,
+		AttachStderr: true,
+		AttachStdout: true,
+		Detach:       false,
+		DetachKeys:   "",
+		Env:          []string{},
+		WorkingDir:   "/",
+		Cmd:          command,
+	}
+
+	response, err := dr.Client.ContainerExecCreate(ctx, dr.ContainerId, *execCreateConfig)
+	if err != nil {
+		return "", err
+	}
+	if response.ID == "" {
+		return "", errors.New("exec ID empty")
+	}
+
+	execStartConfig := &types.ExecStartCheck{
+		Detach: execCreateConfig.Detach,
+		Tty:    execCreateConfig.Tty,
+	}
+
+	attach, err := dr.Client.ContainerExecAttach(ctx, response.ID, *execStartConfig)
+	if err != nil {
+		return "", err
+	}
+	defer attach.Close()
+
+	// The hijacked connection does not follow the context
+	if err := attach.Conn.SetDeadline(time.Now().Add(dr.Timeout)); err != nil {
+		return "", err
+	}
+
+	// Output of the exec is multiplexed since no TTY is used
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attach.Reader); err != nil {
+		return "", err
+	}
+
+	// The output ends when the process exits, but the exit code may take a moment to be reported
+	for {
+		inspect, err := dr.Client.ContainerExecInspect(ctx, response.ID)
+		if err != nil {
+			return "", err
+		}
+
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return stdout.String(), &CommandError{Command: command, ExitCode: inspect.ExitCode, Stderr: stderr.String()}
+			}
+			return stdout.String(), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("%s: timed out after %s", strings.Join(command, " "), dr.Timeout)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package bind_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/services/bind"
+)
+
+func TestLocalRunner(t *testing.T) {
+	runner := &bind.LocalRunner{Timeout: time.Second}
+
+	output, err := runner.Run("sh", "-c", "echo zone loaded")
+	assert.Nil(t, err)
+	assert.Equal(t, "zone loaded\n", output)
+
+	_, err = runner.Run("sh", "-c", "echo partial; echo \"rndc: 'reload' failed: not found\" >&2; exit 1")
+	var cmdErr *bind.CommandError
+	if assert.True(t, errors.As(err, &cmdErr)) {
+		assert.Equal(t, 1, cmdErr.ExitCode)
+		assert.Equal(t, "sh -c echo partial; echo \"rndc: 'reload' failed: not found\" >&2; exit 1: rndc: 'reload' failed: not found", err.Error())
+	}
+
+	runner.Timeout = 100 * time.Millisecond
+	_, err = runner.Run("sleep", "5")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,27 @@
+package bind
+
+import (
+	"log"
+
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Validates the records of a changed zone. Only issues introduced by the change are taken into account,
+// so zones with existing problems can still be fixed. Warnings are logged, or block the change in strict mode.
+func (bs *BindService) validateZone(previous []*parser.Issue, candidate *parser.ZoneConf) error {
+	blocking := []*parser.Issue{}
+
+	for _, issue := range parser.NewIssues(previous, candidate.Validate()) {
+		if issue.Severity == parser.SeverityError || bs.Setting.StrictValidation {
+			blocking = append(blocking, issue)
+		} else {
+			log.Printf("Zone %s: %s\n", candidate.Origin, issue)
+		}
+	}
+
+	if len(blocking) > 0 {
+		return &parser.ValidationError{Origin: candidate.Origin, Issues: blocking}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package bind
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Checks the BIND files for changes made out of the API every interval, and loads them.
+func (bs *BindService) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		// Files are checked along with the changes of the zones, the lock is only held to load them
+		bs.Mutex.RLock()
+		changed := bs.Tracker.Changed()
+		bs.Mutex.RUnlock()
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		bs.Mutex.Lock()
+		bs.ingestChanges(changed)
+		bs.Mutex.Unlock()
+	}
+}
+
+// Loads the files changed out of the API since they were loaded or written. Files that can not
+// be loaded are left untracked, so the API keeps refusing to overwrite them until they are fixed.
+func (bs *BindService) ingestChanges(changed []string) {
+	loaded := []string{}
+
+	if bs.confChanged(changed) {
+		fmt.Printf(">>> Loading configuration changed out of the API: %v\n", changed)
+
+		// The previous configuration is kept if the changed one can not be loaded
+		if err := bs.Load(); err != nil {
+			log.Printf("Error loading configuration changed out of the API: %s\n", err)
+			return
+		}
+		loaded = append(loaded, changed...)
+	} else {
+		for _, filename := range changed {
+			if err := bs.loadFile(filename); err != nil {
+				log.Printf("Error loading %s changed out of the API: %s\n", filename, err)
+				continue
+			}
+
+			if err := bs.Tracker.Track(filename); err != nil {
+				log.Printf("Error tracking file %s: %s\n", filename, err)
+				continue
+			}
+
+			fmt.Printf(">>> Loaded %s changed out of the API\n", filename)
+			loaded = append(loaded, filename)
+		}
+	}
+
+	// The changes are recorded, otherwise the git store would refuse to start on them
+	if bs.Git != nil && len(loaded) > 0 {
+		files := append(bs.takeChanged(), loaded...)
+
+		change := gitstore.Change{Actor: "bind-api", Endpoint: "watch", Message: "Files changed out of the API"}
+		if _, err := bs.Git.Commit(files, change); err != nil {
+			log.Printf("Error committing files changed out of the API: %s\n", err)
+		}
+	}
+}
+
+// Returns true if named.conf.local or named.conf.options are among the changed files.
+func (bs *BindService) confChanged(changed []string) bool {
+	for _, filename := range changed {
+		if filename == filepath.Clean(bs.ZonesFilePath) || filename == filepath.Clean(bs.OptionsFilePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Loads the changed file of a primary zone, parsed the same way as when the configuration is loaded.
+func (bs *BindService) loadFile(filename string) error {
+	zone := bs.fileZone(filename)
+	if zone == nil {
+		return fmt.Errorf("no zone is declared with the file")
+	}
+
+	if zone.Name == bs.Setting.CatalogZone {
+		return bs.loadCatalog()
+	}
+
+	for _, name := range bs.OptionsConf.ResponsePolicyZones() {
+		if name == zone.Name {
+			rz, err := bs.loadRPZone(zone)
+			if err != nil {
+				return err
+			}
+
+			bs.setRPZone(rz.Origin, rz)
+			return nil
+		}
+	}
+
+	zConf, err := bs.loadZoneFile(zone)
+	if err != nil {
+		return err
+	}
+
+	bs.setZone(zConf.Origin, zConf)
+
+	return nil
+}
+
+// Returns the primary zone declared with the file, nil if there is none.
+func (bs *BindService) fileZone(filename string) *parser.Zone {
+	for _, zone := range bs.BindConf.Zones {
+		if !zone.IsPrimary() {
+			continue
+		}
+
+		if zoneFile, err := bs.resolvePath(zone.File); err == nil && filepath.Clean(zoneFile) == filename {
+			return zone
+		}
+	}
+	return nil
+}
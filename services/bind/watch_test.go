@@ -0,0 +1,53 @@
+package bind_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/internal/tests"
+	"github.com/svex99/bind-api/pkg/setting"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+func TestWatchChanges(t *testing.T) {
+	zConf := &parser.ZoneConf{
+		Origin:    "example.com",
+		Ttl:       "1d",
+		SOARecord: &parser.SOARecord{NameServer: "ns1", Admin: "admin", Serial: 1, Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60},
+		Records:   []parser.Record{parser.NSRecord{Type: "NS", NameServer: "ns1"}},
+	}
+
+	dynamicZConf := *zConf
+	dynamicZConf.Origin = "example.org"
+
+	bindService, _ := tests.NewBindService(t, map[string]string{
+		"/etc/bind/named.conf.local": "zone \"example.com\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.com\";\n};\n" +
+			"zone \"example.org\" {\n\ttype master;\n\tfile \"/var/lib/bind/db.example.org\";\n\tallow-update { 127.0.0.1; };\n};\n",
+		"/etc/bind/named.conf.options": "options {\n\tdirectory \"/var/cache/bind\";\n};\n",
+		"/var/lib/bind/db.example.com": zConf.String(),
+		"/var/lib/bind/db.example.org": dynamicZConf.String(),
+	}, func(bindSetting *setting.BindSetting) {
+		bindSetting.WatchInterval = 20 * time.Millisecond
+	})
+
+	// The file of a dynamic zone is written by BIND, it is not a conflict
+	if err := os.WriteFile(bindService.Setting.LibPath+"db.example.org", []byte(dynamicZConf.String()+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Zones changed out of the API are loaded while the API keeps serving changes
+	zConf.Records = append(zConf.Records, parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.1"})
+	if err := os.WriteFile(bindService.Setting.LibPath+"db.example.com", []byte(zConf.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Eventually(t, func() bool {
+		loaded, err := bindService.GetZone("example.com")
+		return err == nil && len(loaded.Records) == 2
+	}, 2*time.Second, 20*time.Millisecond)
+
+	assert.Nil(t, bindService.AddRecord("example.com", parser.ARecord{Type: "A", Name: "ftp", Ip: "10.0.0.2"}))
+	assert.Nil(t, bindService.AddRecord("example.org", parser.ARecord{Type: "A", Name: "ftp", Ip: "10.0.0.2"}))
+}
@@ -0,0 +1,535 @@
+package fleet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/svex99/bind-api/pkg/gitstore"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/bind"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Server of the fleet, managed through its own backend.
+type Server struct {
+	Name    string
+	Backend backend.Backend
+}
+
+// Backend that manages several servers as one. Each zone is held by one or more servers and its
+// changes are applied in all of them. The zones held by a server are the ones loaded by its backend,
+// so the assignment of the zones is read from the servers themselves and never gets out of date.
+type Fleet struct {
+	// Changes of the assignment of a zone hold it for writing, so they don't miss other changes of the zone
+	mutex   *sync.RWMutex
+	servers []*Server
+	// Results of the last change made through a view of the fleet, nil if it is not a view
+	results *[]schemas.ServerResult
+}
+
+// Error of a change that failed in some of the servers, with the result of the change in each of them.
+type FanOutError struct {
+	Results []schemas.ServerResult
+	// Error of the first server that failed
+	err error
+}
+
+func (e *FanOutError) Error() string {
+	failed := []string{}
+	for _, result := range e.Results {
+		if result.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Server, result.Error))
+		}
+	}
+	return strings.Join(failed, "; ")
+}
+
+func (e *FanOutError) Unwrap() error {
+	return e.err
+}
+
+// Returns true if the change was applied in some server, so the servers drifted apart.
+func (e *FanOutError) Applied() bool {
+	for _, result := range e.Results {
+		if result.Error == "" {
+			return true
+		}
+	}
+	return false
+}
+
+var _ backend.Backend = (*Fleet)(nil)
+var _ backend.Recorder = (*Fleet)(nil)
+
+func New(servers ...*Server) (*Fleet, error) {
+	if len(servers) == 0 {
+		return nil, errors.New("the fleet needs at least one server")
+	}
+
+	names := map[string]bool{}
+	for _, server := range servers {
+		if server.Name == "" || names[server.Name] {
+			return nil, fmt.Errorf("server name %q is empty or repeated", server.Name)
+		}
+		names[server.Name] = true
+	}
+
+	return &Fleet{mutex: &sync.RWMutex{}, servers: servers}, nil
+}
+
+// Returns the servers with the given names, in the order of the fleet.
+func (f *Fleet) find(names []string) ([]*Server, error) {
+	existing := map[string]bool{}
+	for _, server := range f.servers {
+		existing[server.Name] = true
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range names {
+		if !existing[name] {
+			return nil, fmt.Errorf("server %s does not exist", name)
+		}
+		wanted[name] = true
+	}
+
+	servers := []*Server{}
+	for _, server := range f.servers {
+		if wanted[server.Name] {
+			servers = append(servers, server)
+		}
+	}
+
+	return servers, nil
+}
+
+// Returns the backend of the server.
+func (f *Fleet) Server(name string) (backend.Backend, error) {
+	servers, err := f.find([]string{name})
+	if err != nil {
+		return nil, err
+	}
+
+	return servers[0].Backend, nil
+}
+
+// Returns the servers holding the zone.
+func (f *Fleet) holders(origin string) ([]*Server, error) {
+	servers := []*Server{}
+	for _, server := range f.servers {
+		if _, err := server.Backend.GetZone(origin); err == nil {
+			servers = append(servers, server)
+		}
+	}
+
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	return servers, nil
+}
+
+// Applies the change in each server at the same time and returns the result in each of them. The error lists
+// the results as well if the change failed in any server, the servers where it succeeded are not rolled back.
+func (f *Fleet) fanOut(servers []*Server, change func(i int, server *Server) error) ([]schemas.ServerResult, error) {
+	errs := make([]error, len(servers))
+
+	wg := sync.WaitGroup{}
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server *Server) {
+			defer wg.Done()
+			errs[i] = change(i, server)
+		}(i, server)
+	}
+	wg.Wait()
+
+	fanOutErr := &FanOutError{Results: make([]schemas.ServerResult, len(servers))}
+	for i, server := range servers {
+		fanOutErr.Results[i].Server = server.Name
+		if errs[i] != nil {
+			fanOutErr.Results[i].Error = errs[i].Error()
+			if fanOutErr.err == nil {
+				fanOutErr.err = errs[i]
+			}
+		}
+	}
+
+	if f.results != nil {
+		*f.results = fanOutErr.Results
+	}
+
+	if fanOutErr.err != nil {
+		return fanOutErr.Results, fanOutErr
+	}
+
+	return fanOutErr.Results, nil
+}
+
+// Returns a view of the fleet that keeps the result in each server of the last change made through it,
+// so it can be reported when the change succeeds too. A view is meant to be used by a single request.
+func (f *Fleet) WithResults() *Fleet {
+	view := *f
+	view.results = &[]schemas.ServerResult{}
+
+	return &view
+}
+
+// Returns the result in each server of the last change made through the view, empty if it is not a view.
+func (f *Fleet) Results() []schemas.ServerResult {
+	if f.results == nil {
+		return []schemas.ServerResult{}
+	}
+
+	return *f.results
+}
+
+// Returns the first zone of the results that is not nil.
+func firstZone(zones []*parser.ZoneConf) *parser.ZoneConf {
+	for _, zConf := range zones {
+		if zConf != nil {
+			return zConf
+		}
+	}
+	return nil
+}
+
+// Lists the zones of every server. A zone held by several servers is returned as it is in the first of them.
+func (f *Fleet) ListZones() []*parser.ZoneConf {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	zones := []*parser.ZoneConf{}
+	seen := map[string]bool{}
+
+	for _, server := range f.servers {
+		for _, zConf := range server.Backend.ListZones() {
+			if !seen[zConf.Origin] {
+				seen[zConf.Origin] = true
+				zones = append(zones, zConf)
+			}
+		}
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Origin < zones[j].Origin })
+
+	return zones
+}
+
+// Returns the zone as it is in the first server holding it.
+func (f *Fleet) GetZone(origin string) (*parser.ZoneConf, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	servers, err := f.holders(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	return servers[0].Backend.GetZone(origin)
+}
+
+// Creates the zone in the servers of the data, or in every server if none is given.
+func (f *Fleet) CreateZone(data *schemas.ZoneData) (*parser.ZoneConf, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if _, err := f.holders(data.Origin); err == nil {
+		return nil, fmt.Errorf("zone %s exists already", data.Origin)
+	}
+
+	servers := f.servers
+	if len(data.Servers) > 0 {
+		var err error
+		if servers, err = f.find(data.Servers); err != nil {
+			return nil, err
+		}
+	}
+
+	zones := make([]*parser.ZoneConf, len(servers))
+	_, err := f.fanOut(servers, func(i int, server *Server) error {
+		var err error
+		zones[i], err = server.Backend.CreateZone(data)
+		return err
+	})
+
+	return firstZone(zones), err
+}
+
+func (f *Fleet) UpdateZone(origin string, data *schemas.ZoneData) (*parser.ZoneConf, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	servers, err := f.holders(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]*parser.ZoneConf, len(servers))
+	_, err = f.fanOut(servers, func(i int, server *Server) error {
+		var err error
+		zones[i], err = server.Backend.UpdateZone(origin, data)
+		return err
+	})
+
+	return firstZone(zones), err
+}
+
+func (f *Fleet) DeleteZone(origin string) error {
+	return f.change(origin, func(b backend.Backend) error {
+		return b.DeleteZone(origin)
+	})
+}
+
+func (f *Fleet) AddRecord(origin string, record parser.Record) error {
+	return f.change(origin, func(b backend.Backend) error {
+		return b.AddRecord(origin, record)
+	})
+}
+
+func (f *Fleet) UpdateRecord(origin, target string, record parser.Record) error {
+	return f.change(origin, func(b backend.Backend) error {
+		return b.UpdateRecord(origin, target, record)
+	})
+}
+
+func (f *Fleet) DeleteRecord(origin string, record parser.Record) error {
+	return f.change(origin, func(b backend.Backend) error {
+		return b.DeleteRecord(origin, record)
+	})
+}
+
+// Reloads the zone in the servers holding it, or every server if origin is empty.
+func (f *Fleet) Reload(origin string) error {
+	if origin != "" {
+		return f.change(origin, func(b backend.Backend) error {
+			return b.Reload(origin)
+		})
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	_, err := f.fanOut(f.servers, func(i int, server *Server) error {
+		return server.Backend.Reload("")
+	})
+
+	return err
+}
+
+// Applies the change of the zone in the servers holding it.
+func (f *Fleet) change(origin string, change func(b backend.Backend) error) error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	servers, err := f.holders(origin)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.fanOut(servers, func(i int, server *Server) error {
+		return change(server.Backend)
+	})
+
+	return err
+}
+
+// Lists the servers of the fleet with the zones they hold.
+func (f *Fleet) Servers() []*schemas.ServerInfo {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	servers := []*schemas.ServerInfo{}
+	for _, server := range f.servers {
+		info := &schemas.ServerInfo{Name: server.Name, Zones: []string{}}
+		for _, zConf := range server.Backend.ListZones() {
+			info.Zones = append(info.Zones, zConf.Origin)
+		}
+		servers = append(servers, info)
+	}
+
+	return servers
+}
+
+// Assigns the zone to the given servers. The zone is copied, as it is in the first server holding it,
+// to the servers that don't hold it yet, and deleted from the servers not given. Returns the result
+// in each server that was changed.
+func (f *Fleet) AssignZone(origin string, names []string) ([]schemas.ServerResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(names) == 0 {
+		return nil, errors.New("a zone must be assigned to at least one server")
+	}
+
+	assigned, err := f.find(names)
+	if err != nil {
+		return nil, err
+	}
+
+	holders, err := f.holders(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := holders[0].Backend.GetZone(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	held := map[string]bool{}
+	for _, server := range holders {
+		held[server.Name] = true
+	}
+
+	wanted := map[string]bool{}
+	for _, server := range assigned {
+		wanted[server.Name] = true
+	}
+
+	changed := []*Server{}
+	for _, server := range f.servers {
+		if held[server.Name] != wanted[server.Name] {
+			changed = append(changed, server)
+		}
+	}
+
+	return f.fanOut(changed, func(i int, server *Server) error {
+		if held[server.Name] {
+			return server.Backend.DeleteZone(origin)
+		}
+		return copyZone(server.Backend, source)
+	})
+}
+
+// Creates the zone in the backend with the records of the given one. Backends that can't import
+// a zone get its records added one by one.
+func copyZone(b backend.Backend, zConf *parser.ZoneConf) error {
+	if importer, ok := b.(backend.Importer); ok {
+		_, err := importer.ImportZone(zConf)
+		return err
+	}
+
+	data := &schemas.ZoneData{
+		Origin:     zConf.Origin,
+		Ttl:        zConf.Ttl,
+		NameServer: zConf.SOARecord.NameServer,
+		Admin:      zConf.SOARecord.Admin,
+		Refresh:    zConf.SOARecord.Refresh,
+		Retry:      zConf.SOARecord.Retry,
+		Expire:     zConf.SOARecord.Expire,
+		Minimum:    zConf.SOARecord.Minimum,
+	}
+
+	if _, err := b.CreateZone(data); err != nil {
+		return err
+	}
+
+	for _, record := range zConf.Records {
+		if err := b.AddRecord(zConf.Origin, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the zones whose content differs between the servers holding them. The serials are left out
+// of the comparison, since each server increments them on its own, but are reported along the digests.
+func (f *Fleet) Drift() []*schemas.ZoneDrift {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	states := map[string][]*schemas.ZoneState{}
+	origins := []string{}
+
+	for _, server := range f.servers {
+		for _, zConf := range server.Backend.ListZones() {
+			if _, ok := states[zConf.Origin]; !ok {
+				origins = append(origins, zConf.Origin)
+			}
+
+			states[zConf.Origin] = append(states[zConf.Origin], &schemas.ZoneState{
+				Server: server.Name,
+				Serial: zConf.SOARecord.Serial,
+				Digest: digest(zConf),
+			})
+		}
+	}
+
+	sort.Strings(origins)
+
+	drifts := []*schemas.ZoneDrift{}
+	for _, origin := range origins {
+		for _, state := range states[origin][1:] {
+			if state.Digest != states[origin][0].Digest {
+				drifts = append(drifts, &schemas.ZoneDrift{Origin: origin, Servers: states[origin]})
+				break
+			}
+		}
+	}
+
+	return drifts
+}
+
+// Returns the digest of the content of the zone, without its serial. Records are sorted first,
+// their order does not change the answers of the server.
+func digest(zConf *parser.ZoneConf) string {
+	soa := *zConf.SOARecord
+	soa.Serial = 0
+
+	records := []string{}
+	for _, record := range zConf.Records {
+		records = append(records, record.String())
+	}
+	records = append(records, zConf.Other...)
+	sort.Strings(records)
+
+	content := fmt.Sprintf("%s\n%s\n%+v\n%s", zConf.Origin, zConf.Ttl, soa, strings.Join(records, "\n"))
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns true if any server commits its changes to a git store.
+func (f *Fleet) Recording() bool {
+	for _, server := range f.servers {
+		if bs, ok := server.Backend.(*bind.BindService); ok && bs.Git != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a view of the fleet whose servers keep the files changed through it, see bind.BindService.WithChanges.
+// A view is meant to be used by a single request.
+func (f *Fleet) WithChanges() backend.Recorder {
+	view := *f
+	view.servers = make([]*Server, len(f.servers))
+
+	for i, server := range f.servers {
+		view.servers[i] = server
+		if recorder, ok := server.Backend.(backend.Recorder); ok {
+			view.servers[i] = &Server{Name: server.Name, Backend: recorder.WithChanges()}
+		}
+	}
+
+	return &view
+}
+
+// Commits the files changed through the view in each server to its own git store, see middlewares.RecordChanges.
+func (f *Fleet) RecordChange(change gitstore.Change) error {
+	_, err := f.fanOut(f.servers, func(i int, server *Server) error {
+		if recorder, ok := server.Backend.(backend.Recorder); ok {
+			return recorder.RecordChange(change)
+		}
+		return nil
+	})
+
+	return err
+}
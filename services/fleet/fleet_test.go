@@ -0,0 +1,114 @@
+package fleet_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/bind/parser"
+	"github.com/svex99/bind-api/services/fleet"
+	"github.com/svex99/bind-api/services/memory"
+)
+
+func TestFleet(t *testing.T) {
+	ns1, ns2, ns3 := memory.NewBackend(), memory.NewBackend(), memory.NewBackend()
+
+	f, err := fleet.New(
+		&fleet.Server{Name: "ns1", Backend: ns1},
+		&fleet.Server{Name: "ns2", Backend: ns2},
+		&fleet.Server{Name: "ns3", Backend: ns3},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fleet.New(&fleet.Server{Name: "ns1", Backend: ns1}, &fleet.Server{Name: "ns1", Backend: ns2})
+	assert.NotNil(t, err)
+
+	zone := &schemas.ZoneData{
+		Origin: "example.com", Ttl: "1d", NameServer: "ns1", Admin: "admin",
+		Refresh: 3600, Retry: 600, Expire: 86400, Minimum: 60,
+		Servers: []string{"ns1", "ns2"},
+	}
+
+	// Zones are created in the given servers and their changes applied in every one of them
+	_, err = f.CreateZone(zone)
+	assert.Nil(t, err)
+
+	_, err = f.CreateZone(zone)
+	assert.NotNil(t, err)
+
+	record := parser.ARecord{Type: "A", Name: "www", Ip: "10.0.0.1"}
+	assert.Nil(t, f.AddRecord("example.com", record))
+
+	for _, b := range []*memory.MemoryBackend{ns1, ns2} {
+		zConf, err := b.GetZone("example.com")
+		if assert.Nil(t, err) {
+			assert.Equal(t, []parser.Record{record}, zConf.Records)
+		}
+	}
+
+	_, err = ns3.GetZone("example.com")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []*schemas.ServerInfo{
+		{Name: "ns1", Zones: []string{"example.com"}},
+		{Name: "ns2", Zones: []string{"example.com"}},
+		{Name: "ns3", Zones: []string{}},
+	}, f.Servers())
+
+	assert.Empty(t, f.Drift())
+
+	// Changes made in a single server drift it apart from the others
+	other := parser.ARecord{Type: "A", Name: "mail", Ip: "10.0.0.2"}
+	assert.Nil(t, ns2.AddRecord("example.com", other))
+
+	drifts := f.Drift()
+	if assert.Len(t, drifts, 1) {
+		assert.Equal(t, "example.com", drifts[0].Origin)
+		assert.Len(t, drifts[0].Servers, 2)
+	}
+
+	// The result in each server is reported when the change fails in some of them
+	err = f.AddRecord("example.com", other)
+
+	var fanOutErr *fleet.FanOutError
+	if assert.True(t, errors.As(err, &fanOutErr)) {
+		assert.True(t, fanOutErr.Applied())
+		assert.Equal(t, "ns1", fanOutErr.Results[0].Server)
+		assert.Empty(t, fanOutErr.Results[0].Error)
+		assert.Equal(t, "ns2", fanOutErr.Results[1].Server)
+		assert.NotEmpty(t, fanOutErr.Results[1].Error)
+	}
+
+	assert.Empty(t, f.Drift())
+
+	// Views of the fleet keep the result in each server of their last change
+	view := f.WithResults()
+	assert.Nil(t, view.DeleteRecord("example.com", other))
+	assert.Equal(t, []schemas.ServerResult{{Server: "ns1"}, {Server: "ns2"}}, view.Results())
+	assert.Empty(t, f.Results())
+	assert.Nil(t, f.AddRecord("example.com", other))
+
+	// Assigned zones are copied to the new servers and deleted from the ones left out
+	results, err := f.AssignZone("example.com", []string{"ns2", "ns3"})
+	assert.Nil(t, err)
+	assert.Equal(t, []schemas.ServerResult{{Server: "ns1"}, {Server: "ns3"}}, results)
+
+	_, err = ns1.GetZone("example.com")
+	assert.NotNil(t, err)
+
+	zConf, err := ns3.GetZone("example.com")
+	if assert.Nil(t, err) {
+		assert.ElementsMatch(t, []parser.Record{record, other}, zConf.Records)
+	}
+
+	assert.Empty(t, f.Drift())
+
+	_, err = f.AssignZone("example.com", []string{"ns4"})
+	assert.NotNil(t, err)
+
+	assert.Nil(t, f.DeleteZone("example.com"))
+	assert.Empty(t, f.ListZones())
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/svex99/bind-api/schemas"
+	"github.com/svex99/bind-api/services/backend"
+	"github.com/svex99/bind-api/services/bind/parser"
+)
+
+// Backend that keeps the zones in memory, used to embed the API and to test it without a DNS server.
+type MemoryBackend struct {
+	mutex sync.Mutex
+	zones map[string]*parser.ZoneConf
+}
+
+var _ backend.Backend = (*MemoryBackend)(nil)
+
+func NewBackend() *MemoryBackend {
+	return &MemoryBackend{zones: make(map[string]*parser.ZoneConf)}
+}
+
+func (mb *MemoryBackend) ListZones() []*parser.ZoneConf {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	zones := []*parser.ZoneConf{}
+	for _, zConf := range mb.zones {
+		zones = append(zones, zConf)
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Origin < zones[j].Origin })
+
+	return zones
+}
+
+func (mb *MemoryBackend) GetZone(origin string) (*parser.ZoneConf, error) {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	zConf, ok := mb.zones[origin]
+	if !ok {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	return zConf, nil
+}
+
+func (mb *MemoryBackend) CreateZone(data *schemas.ZoneData) (*parser.ZoneConf, error) {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	if _, ok := mb.zones[data.Origin]; ok {
+		return nil, fmt.Errorf("zone %s exists already", data.Origin)
+	}
+
+	zConf := &parser.ZoneConf{
+		Origin: data.Origin,
+		Ttl:    data.Ttl,
+		SOARecord: &parser.SOARecord{
+			NameServer: data.NameServer,
+			Admin:      data.Admin,
+			Refresh:    data.Refresh,
+			Retry:      data.Retry,
+			Expire:     data.Expire,
+			Minimum:    data.Minimum,
+		},
+		Records: []parser.Record{},
+	}
+	zConf.UpdateSerial()
+
+	mb.zones[zConf.Origin] = zConf
+
+	return zConf, nil
+}
+
+func (mb *MemoryBackend) UpdateZone(origin string, data *schemas.ZoneData) (*parser.ZoneConf, error) {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	current, ok := mb.zones[origin]
+	if !ok {
+		return nil, fmt.Errorf("zone %s does not exist", origin)
+	}
+
+	zConf := *current
+	soa := *current.SOARecord
+
+	zConf.Ttl = data.Ttl
+	zConf.SOARecord = &soa
+	zConf.SOARecord.NameServer = data.NameServer
+	zConf.SOARecord.Admin = data.Admin
+	zConf.SOARecord.Refresh = data.Refresh
+	zConf.SOARecord.Retry = data.Retry
+	zConf.SOARecord.Expire = data.Expire
+	zConf.SOARecord.Minimum = data.Minimum
+	zConf.UpdateSerial()
+
+	mb.zones[origin] = &zConf
+
+	return &zConf, nil
+}
+
+func (mb *MemoryBackend) DeleteZone(origin string) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	if _, ok := mb.zones[origin]; !ok {
+		return fmt.Errorf("domain %s does not exist", origin)
+	}
+
+	delete(mb.zones, origin)
+
+	return nil
+}
+
+func (mb *MemoryBackend) AddRecord(origin string, record parser.Record) error {
+	return mb.updateZone(origin, func(zConf *parser.ZoneConf) error {
+		return zConf.AddRecord(record)
+	})
+}
+
+func (mb *MemoryBackend) UpdateRecord(origin, target string, record parser.Record) error {
+	return mb.updateZone(origin, func(zConf *parser.ZoneConf) error {
+		return zConf.UpdateRecord(target, record)
+	})
+}
+
+func (mb *MemoryBackend) DeleteRecord(origin string, record parser.Record) error {
+	return mb.updateZone(origin, func(zConf *parser.ZoneConf) error {
+		return zConf.DeleteRecord(record)
+	})
+}
+
+// There is no server to notify, only checks that the zone exists.
+func (mb *MemoryBackend) Reload(origin string) error {
+	if origin == "" {
+		return nil
+	}
+
+	_, err := mb.GetZone(origin)
+
+	return err
+}
+
+// Applies the changes of `update` to a copy of the zone, the zone is replaced only if they succeed.
+func (mb *MemoryBackend) updateZone(origin string, update func(zConf *parser.ZoneConf) error) error {
+	mb.mutex.Lock()
+	defer mb.mutex.Unlock()
+
+	current, ok := mb.zones[origin]
+	if !ok {
+		return errors.New("origin not found")
+	}
+
+	zConf := *current
+	soa := *current.SOARecord
+	zConf.SOARecord = &soa
+	zConf.Records = append([]parser.Record{}, current.Records...)
+
+	if err := update(&zConf); err != nil {
+		return err
+	}
+	zConf.UpdateSerial()
+
+	mb.zones[origin] = &zConf
+
+	return nil
+}